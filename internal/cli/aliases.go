@@ -5,11 +5,22 @@ import "github.com/odysseus0/feed/internal/model"
 type OutputFormat = model.OutputFormat
 type Feed = model.Feed
 type Entry = model.Entry
+type EntryRevision = model.EntryRevision
+type Enclosure = model.Enclosure
+type Truncation = model.Truncation
+type EntryEnclosure = model.EntryEnclosure
+type MediaDownloadResult = model.MediaDownloadResult
 type Stats = model.Stats
 type FetchResult = model.FetchResult
 type FetchReport = model.FetchReport
 type EntryListOptions = model.EntryListOptions
+type EnclosureListOptions = model.EnclosureListOptions
+type FeedListOptions = model.FeedListOptions
+type FetchOptions = model.FetchOptions
 type SearchOptions = model.SearchOptions
+type UpdateFeedInput = model.UpdateFeedInput
+type AddFeedResponse = model.AddFeedResponse
+type BatchUpdateEntriesResponse = model.BatchUpdateEntriesResponse
 
 const (
 	OutputTable = model.OutputTable
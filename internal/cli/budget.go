@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// charsPerToken approximates tokens for --max-tokens; English prose averages
+// about four characters per token.
+const charsPerToken = 4
+
+func addBudgetFlags(cmd *cobra.Command, maxChars, maxTokens *int) {
+	cmd.Flags().IntVar(maxChars, "max-chars", 0, "Cap the text of all entries at this many characters, shared fairly across entries")
+	cmd.Flags().IntVar(maxTokens, "max-tokens", 0, "Like --max-chars, counting ~4 characters per token")
+}
+
+// outputBudget reads --max-chars and --max-tokens into a character budget,
+// where 0 means unlimited.
+func outputBudget(maxChars, maxTokens int) (int, error) {
+	switch {
+	case maxChars < 0:
+		return 0, fmt.Errorf("%w: --max-chars must be positive", store.ErrInvalidInput)
+	case maxTokens < 0:
+		return 0, fmt.Errorf("%w: --max-tokens must be positive", store.ErrInvalidInput)
+	case maxChars > 0 && maxTokens > 0:
+		return 0, fmt.Errorf("%w: --max-chars and --max-tokens are mutually exclusive", store.ErrInvalidInput)
+	case maxTokens > 0:
+		return maxTokens * charsPerToken, nil
+	default:
+		return maxChars, nil
+	}
+}
+
+// fairShares splits budget across texts of the given lengths: texts that fit
+// in an equal share keep all of it, and what they leave over is split among
+// the rest, so one long entry cannot crowd out the others.
+func fairShares(lengths []int, budget int) []int {
+	order := make([]int, len(lengths))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return lengths[order[a]] < lengths[order[b]] })
+
+	shares := make([]int, len(lengths))
+	remaining := budget
+	for i, idx := range order {
+		share := remaining / (len(order) - i)
+		shares[idx] = min(lengths[idx], share)
+		remaining -= shares[idx]
+	}
+	return shares
+}
+
+// cutText returns at most limit characters of text, backing up to a word
+// boundary when one is close to the cut.
+func cutText(text []rune, limit int) []rune {
+	if limit >= len(text) {
+		return text
+	}
+	cut := limit
+	for i := limit; i > 0 && limit-i < 80; i-- {
+		if unicode.IsSpace(text[i]) {
+			cut = i
+			break
+		}
+	}
+	return text[:cut]
+}
+
+// budgetSummaries shares budget across entry summaries for listings. Content
+// fields are dropped, since listings show only summaries.
+func budgetSummaries(entries []Entry, budget int) {
+	texts := make([][]rune, len(entries))
+	for i, e := range entries {
+		texts[i] = []rune(strings.TrimSpace(e.Summary))
+	}
+	shown, shares := budgetTexts(texts, budget)
+	for i := range entries {
+		e := &entries[i]
+		e.ContentHTML, e.ContentMD = "", ""
+		e.FullContentHTML, e.FullContentMD = "", ""
+		e.Summary = string(shown[i])
+		if len(shown[i]) < len(texts[i]) {
+			e.Truncated = &Truncation{Field: "summary", TotalChars: len(texts[i]), ShownChars: len(shown[i]), MaxChars: shares[i]}
+		}
+	}
+}
+
+// budgetContents shares budget across the Markdown bodies `feed get entry`
+// prints, each starting offset characters in. The body is kept in the field
+// it came from and the other text fields are dropped.
+func budgetContents(entries []Entry, budget, offset int) {
+	fields := make([]string, len(entries))
+	totals := make([]int, len(entries))
+	texts := make([][]rune, len(entries))
+	for i, e := range entries {
+		var body string
+		fields[i], body = entryBody(e)
+		text := []rune(body)
+		totals[i] = len(text)
+		texts[i] = text[min(offset, len(text)):]
+	}
+	shown, shares := budgetTexts(texts, budget)
+	for i := range entries {
+		e := &entries[i]
+		body := string(shown[i])
+		e.ContentHTML, e.FullContentHTML = "", ""
+		e.ContentMD, e.FullContentMD, e.Summary = "", "", ""
+		switch fields[i] {
+		case "full_content_md":
+			e.FullContentMD = body
+		case "content_md":
+			e.ContentMD = body
+		default:
+			e.Summary = body
+		}
+		if offset > 0 || len(shown[i]) < len(texts[i]) {
+			e.Truncated = &Truncation{Field: fields[i], TotalChars: totals[i], Offset: min(offset, totals[i]), ShownChars: len(shown[i]), MaxChars: shares[i]}
+		}
+	}
+}
+
+// budgetTexts cuts each text to its fair share of budget and returns the
+// shares, which are all zero when there is no budget.
+func budgetTexts(texts [][]rune, budget int) ([][]rune, []int) {
+	if budget <= 0 {
+		return texts, make([]int, len(texts))
+	}
+	lengths := make([]int, len(texts))
+	for i, text := range texts {
+		lengths[i] = len(text)
+	}
+	shares := fairShares(lengths, budget)
+	out := make([][]rune, len(texts))
+	for i, text := range texts {
+		out[i] = cutText(text, shares[i])
+	}
+	return out, shares
+}
+
+// entryBody picks the text `feed get entry` prints: the extracted full
+// article, else the feed content, else the summary.
+func entryBody(e Entry) (field, body string) {
+	if body := strings.TrimSpace(e.FullContentMD); body != "" {
+		return "full_content_md", body
+	}
+	if body := strings.TrimSpace(e.ContentMD); body != "" {
+		return "content_md", body
+	}
+	return "summary", strings.TrimSpace(e.Summary)
+}
+
+// truncationNote tells the reader what a budget cut from an entry and how to
+// read on.
+func truncationNote(e Entry) string {
+	t := e.Truncated
+	if t == nil {
+		return ""
+	}
+	next := t.Offset + t.ShownChars
+	if next >= t.TotalChars {
+		return fmt.Sprintf("[truncated: showing characters %d-%d of %d]", t.Offset, next, t.TotalChars)
+	}
+	command := fmt.Sprintf("feed get entry %d --offset %d", e.ID, next)
+	if t.MaxChars > 0 {
+		command += fmt.Sprintf(" --max-chars %d", t.MaxChars)
+	}
+	return fmt.Sprintf("[truncated: showing characters %d-%d of %d; continue with `%s`]", t.Offset, next, t.TotalChars, command)
+}
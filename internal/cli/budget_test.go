@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOutputBudget(t *testing.T) {
+	if got, err := outputBudget(0, 100); err != nil || got != 400 {
+		t.Fatalf("outputBudget(0, 100) = %d, %v; want 400", got, err)
+	}
+	if got, err := outputBudget(250, 0); err != nil || got != 250 {
+		t.Fatalf("outputBudget(250, 0) = %d, %v; want 250", got, err)
+	}
+	if _, err := outputBudget(10, 10); err == nil {
+		t.Fatalf("expected error when both budgets are set")
+	}
+	if _, err := outputBudget(-1, 0); err == nil {
+		t.Fatalf("expected error for a negative budget")
+	}
+}
+
+func TestFairShares(t *testing.T) {
+	cases := []struct {
+		lengths []int
+		budget  int
+		want    []int
+	}{
+		{[]int{100, 10, 100}, 90, []int{40, 10, 40}},
+		{[]int{5, 5}, 100, []int{5, 5}},
+		{[]int{30, 30, 30}, 30, []int{10, 10, 10}},
+		{[]int{}, 10, []int{}},
+	}
+	for _, tc := range cases {
+		if got := fairShares(tc.lengths, tc.budget); !slices.Equal(got, tc.want) {
+			t.Fatalf("fairShares(%v, %d) = %v, want %v", tc.lengths, tc.budget, got, tc.want)
+		}
+	}
+}
+
+func TestBudgetContents(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, ContentHTML: "<p>long</p>", ContentMD: strings.Repeat("word ", 40), Summary: "s"},
+		{ID: 2, Summary: "short summary"},
+	}
+	budgetContents(entries, 60, 0)
+
+	if entries[0].ContentHTML != "" || entries[0].Summary != "" {
+		t.Fatalf("expected only the budgeted body to remain, got %+v", entries[0])
+	}
+	if n := len(entries[0].ContentMD); n > 47 || !strings.HasPrefix(entries[0].ContentMD, "word word") {
+		t.Fatalf("content cut to %d chars: %q", n, entries[0].ContentMD)
+	}
+	trunc := entries[0].Truncated
+	if trunc == nil || trunc.Field != "content_md" || trunc.TotalChars != 199 || trunc.ShownChars != len(entries[0].ContentMD) || trunc.MaxChars != 47 {
+		t.Fatalf("unexpected truncation: %+v", trunc)
+	}
+	if entries[1].Summary != "short summary" || entries[1].Truncated != nil {
+		t.Fatalf("short entry should be untouched, got %+v", entries[1])
+	}
+	if note := truncationNote(entries[0]); !strings.Contains(note, "feed get entry 1 --offset 44 --max-chars 47`") {
+		t.Fatalf("unexpected note %q", note)
+	}
+
+	rest := []Entry{{ID: 1, ContentMD: strings.Repeat("word ", 40)}}
+	budgetContents(rest, 0, trunc.ShownChars)
+	if got := rest[0].Truncated; got == nil || got.Offset != 44 || got.Offset+got.ShownChars != got.TotalChars {
+		t.Fatalf("unexpected continuation: %+v", got)
+	}
+}
@@ -17,7 +17,9 @@ func newAddCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command
 }
 
 func newAddFeedCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
-	return &cobra.Command{
+	var folder string
+
+	cmd := &cobra.Command{
 		Use:   "feed <url>",
 		Short: "Add a feed URL",
 		Args:  cobra.ExactArgs(1),
@@ -27,34 +29,19 @@ func newAddFeedCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Com
 				return err
 			}
 
-			discovered, err := app.fetcher.DiscoverFeedURL(cmd.Context(), args[0])
-			if err != nil {
-				return fmt.Errorf("discover feed url: %w", err)
-			}
-			if discovered != args[0] {
-				fmt.Fprintf(os.Stderr, "Discovered feed URL: %s\n", discovered)
-			}
-
-			feed, inserted, err := app.store.CreateFeed(cmd.Context(), discovered)
+			added, err := app.fetcher.AddFeed(cmd.Context(), args[0], folder)
 			if err != nil {
-				return fmt.Errorf("create feed: %w", err)
-			}
-			report, err := app.fetcher.Fetch(cmd.Context(), &feed.ID)
-			if err != nil {
-				return fmt.Errorf("initial fetch: %w", err)
+				return err
 			}
-
-			feed, _ = app.store.GetFeedByID(cmd.Context(), feed.ID)
 			if getOutput() == OutputJSON {
-				return writeJSON(os.Stdout, AddFeedResponse{
-					Feed:          feed,
-					Inserted:      inserted,
-					DiscoveredURL: discovered,
-					FetchReport:   report,
-				})
+				return writeJSON(os.Stdout, added)
 			}
 
-			if inserted {
+			feed, report := added.Feed, added.FetchReport
+			if added.DiscoveredURL != args[0] {
+				fmt.Fprintf(os.Stderr, "Discovered feed URL: %s\n", added.DiscoveredURL)
+			}
+			if added.Inserted {
 				fmt.Fprintf(os.Stdout, "Added feed %d: %s\n", feed.ID, fallback(feed.Title, feed.URL))
 			} else {
 				fmt.Fprintf(os.Stdout, "Skipped existing feed (%d): %s\n", feed.ID, fallback(feed.Title, feed.URL))
@@ -70,4 +57,6 @@ func newAddFeedCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Com
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&folder, "folder", "", "Place the feed in this folder")
+	return cmd
 }
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+func newDownloadCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	return &cobra.Command{
+		Use:   "download <entry-id> [entry-id...]",
+		Short: "Download entries' enclosures and images for offline reading",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+
+			ids := make([]int64, 0, len(args))
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return fmt.Errorf("%w: %v", store.ErrInvalidInput, err)
+				}
+				ids = append(ids, id)
+			}
+
+			results := make([]MediaDownloadResult, 0, len(ids))
+			for _, id := range ids {
+				result, err := app.fetcher.DownloadEntryMedia(cmd.Context(), id)
+				if err != nil {
+					return fmt.Errorf("download entry %d: %w", id, err)
+				}
+				for _, msg := range result.Errors {
+					fmt.Fprintf(os.Stderr, "warning: entry %d: %s\n", id, msg)
+				}
+				results = append(results, result)
+			}
+
+			if getOutput() == OutputJSON {
+				return writeJSON(os.Stdout, results)
+			}
+			for _, r := range results {
+				fmt.Fprintf(os.Stdout, "Entry %d: %d downloaded (%s), %d already cached, %d failed\n", r.EntryID, r.Downloaded, formatBytes(r.Bytes), r.Cached, len(r.Errors))
+			}
+			fmt.Fprintf(os.Stderr, "Media directory: %s\n", app.fetcher.MediaDir())
+			return nil
+		},
+	}
+}
+
+// localizeEntryMedia points an entry's links to media that have been
+// downloaded at their local copies.
+func localizeEntryMedia(cmd *cobra.Command, app *App, entry Entry) (Entry, error) {
+	local, err := app.fetcher.LocalMedia(cmd.Context(), entry.ID)
+	if err != nil || len(local) == 0 {
+		return entry, err
+	}
+	remotes := make([]string, 0, len(local))
+	for remote := range local {
+		remotes = append(remotes, remote)
+	}
+	// Replacer compares patterns in argument order; longest first keeps a URL
+	// from being rewritten through a shorter one that is its prefix.
+	sort.Slice(remotes, func(i, j int) bool { return len(remotes[i]) > len(remotes[j]) })
+	var plain, escaped []string
+	for _, remote := range remotes {
+		path := local[remote]
+		fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+		plain = append(plain, remote, fileURL)
+		escaped = append(escaped, html.EscapeString(remote), html.EscapeString(fileURL))
+	}
+	md := strings.NewReplacer(plain...)
+	htm := strings.NewReplacer(escaped...)
+
+	entry.ContentMD = md.Replace(entry.ContentMD)
+	entry.FullContentMD = md.Replace(entry.FullContentMD)
+	entry.Summary = md.Replace(entry.Summary)
+	entry.ContentHTML = htm.Replace(entry.ContentHTML)
+	entry.FullContentHTML = htm.Replace(entry.FullContentHTML)
+	enclosures := make([]Enclosure, len(entry.Enclosures))
+	for i, enc := range entry.Enclosures {
+		enc.URL = md.Replace(enc.URL)
+		enclosures[i] = enc
+	}
+	if len(enclosures) > 0 {
+		entry.Enclosures = enclosures
+	}
+	return entry, nil
+}
@@ -9,9 +9,12 @@ import (
 )
 
 func newFetchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var folder string
+	var force bool
+
 	cmd := &cobra.Command{
 		Use:   "fetch [id]",
-		Short: "Fetch all feeds or one feed by ID",
+		Short: "Fetch feeds that are due, or one feed by ID",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := requireApp(getApp)
@@ -27,8 +30,11 @@ func newFetchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comma
 				id = &v
 			}
 
-			rep, err := app.fetcher.FetchWithProgress(cmd.Context(), id, func(done, total int, result FetchResult) {
+			rep, err := app.fetcher.FetchWithOptions(cmd.Context(), FetchOptions{FeedID: id, Folder: folder, Force: force, DownloadMedia: true}, func(done, total int, result FetchResult) {
 				label := fallback(result.FeedTitle, result.FeedURL)
+				if result.MovedTo != "" {
+					fmt.Fprintf(os.Stderr, "[%d/%d] %s -> moved permanently to %s\n", done, total, label, result.MovedTo)
+				}
 				if result.Error != "" {
 					fmt.Fprintf(os.Stderr, "[%d/%d] %s -> error: %s\n", done, total, label, result.Error)
 					return
@@ -56,12 +62,20 @@ func newFetchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comma
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&folder, "folder", "", "Only fetch feeds in this folder")
+	cmd.Flags().BoolVar(&force, "force", false, "Fetch feeds even if they are not yet due")
 	return cmd
 }
 
 func newSearchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
 	var feedID int64
+	var tags []string
+	var since string
+	var until string
+	var after string
 	var limit int
+	var maxChars int
+	var maxTokens int
 
 	cmd := &cobra.Command{
 		Use:   "search <query>",
@@ -72,14 +86,29 @@ func newSearchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comm
 			if err != nil {
 				return err
 			}
+			sinceT, untilT, err := parseTimeRange(since, until)
+			if err != nil {
+				return err
+			}
+			budget, err := outputBudget(maxChars, maxTokens)
+			if err != nil {
+				return err
+			}
 			entries, err := app.store.SearchEntries(cmd.Context(), SearchOptions{
 				Query: args[0],
 				Feed:  feedID,
+				Tags:  tags,
+				Since: sinceT,
+				Until: untilT,
+				After: after,
 				Limit: limit,
 			})
 			if err != nil {
 				return fmt.Errorf("search entries: %w", err)
 			}
+			if budget > 0 {
+				budgetSummaries(entries, budget)
+			}
 			switch getOutput() {
 			case OutputJSON:
 				return writeJSON(os.Stdout, entries)
@@ -88,13 +117,19 @@ func newSearchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comm
 			default:
 				writeEntriesTable(os.Stdout, entries, false)
 			}
+			printNextPageHint(entries, limit)
 			return nil
 		},
 	}
 	var noFetch bool
 	cmd.Flags().Int64Var(&feedID, "feed", 0, "Filter by feed ID")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Filter by tag (repeatable; entries must have all tags)")
+	cmd.Flags().StringVar(&since, "since", "", "Only entries published at or after this time (date, RFC3339, or duration ago like 6h, 2d)")
+	cmd.Flags().StringVar(&until, "until", "", "Only entries published at or before this time (date, through its end; RFC3339; or duration ago like 6h, 2d)")
+	cmd.Flags().StringVar(&after, "after", "", "Resume search after this cursor (from a previous page)")
 	cmd.Flags().IntVar(&limit, "limit", 50, "Result limit")
 	cmd.Flags().BoolVar(&noFetch, "no-fetch", false, "Accepted for consistency (search never auto-fetches)")
+	addBudgetFlags(cmd, &maxChars, &maxTokens)
 	_ = noFetch
 	return cmd
 }
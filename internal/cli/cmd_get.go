@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/odysseus0/feed/internal/store"
@@ -17,6 +18,7 @@ func newGetCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command
 
 	cmd.AddCommand(newGetEntriesCmd(getApp, getOutput))
 	cmd.AddCommand(newGetEntryCmd(getApp, getOutput))
+	cmd.AddCommand(newGetEnclosuresCmd(getApp, getOutput))
 	cmd.AddCommand(newGetFeedsCmd(getApp, getOutput))
 	cmd.AddCommand(newGetStatsCmd(getApp, getOutput))
 	return cmd
@@ -25,8 +27,15 @@ func newGetCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command
 func newGetEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
 	var status string
 	var feedID int64
+	var folder string
+	var tags []string
+	var since string
+	var until string
+	var after string
 	var limit int
 	var noFetch bool
+	var maxChars int
+	var maxTokens int
 
 	cmd := &cobra.Command{
 		Use:   "entries",
@@ -37,6 +46,14 @@ func newGetEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.
 				return err
 			}
 			ctx := cmd.Context()
+			sinceT, untilT, err := parseTimeRange(since, until)
+			if err != nil {
+				return err
+			}
+			budget, err := outputBudget(maxChars, maxTokens)
+			if err != nil {
+				return err
+			}
 
 			if !noFetch {
 				hasFeeds, stale, lastFetched, err := app.store.GetFetchStaleness(ctx, app.cfg.StaleAfter)
@@ -73,11 +90,19 @@ func newGetEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.
 			entries, err := app.store.ListEntries(ctx, EntryListOptions{
 				Status: status,
 				FeedID: feedID,
+				Folder: folder,
+				Tags:   tags,
+				Since:  sinceT,
+				Until:  untilT,
+				After:  after,
 				Limit:  limit,
 			})
 			if err != nil {
 				return fmt.Errorf("list entries: %w", err)
 			}
+			if budget > 0 {
+				budgetSummaries(entries, budget)
+			}
 
 			switch getOutput() {
 			case OutputJSON:
@@ -87,18 +112,35 @@ func newGetEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.
 			default:
 				writeEntriesTable(os.Stdout, entries, false)
 			}
+			printNextPageHint(entries, limit)
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&status, "status", "unread", "Entry status: unread, read, all")
+	cmd.Flags().StringVar(&status, "status", "unread", "Entry status: unread, read, starred, all (combine with +, e.g. unread+starred)")
 	cmd.Flags().Int64Var(&feedID, "feed", 0, "Filter by feed ID")
+	cmd.Flags().StringVar(&folder, "folder", "", "Filter by folder name")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Filter by tag (repeatable; entries must have all tags)")
+	cmd.Flags().StringVar(&since, "since", "", "Only entries published at or after this time (date, RFC3339, or duration ago like 6h, 2d)")
+	cmd.Flags().StringVar(&until, "until", "", "Only entries published at or before this time (date, through its end; RFC3339; or duration ago like 6h, 2d)")
+	cmd.Flags().StringVar(&after, "after", "", "Resume listing after this cursor (from a previous page)")
 	cmd.Flags().IntVar(&limit, "limit", 50, "Result limit")
 	cmd.Flags().BoolVar(&noFetch, "no-fetch", false, "Skip staleness auto-fetch")
+	addBudgetFlags(cmd, &maxChars, &maxTokens)
 	return cmd
 }
 
 func newGetEntryCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var full bool
+	var localMedia bool
+	var revisions bool
+	var diff bool
+	var fromRev int
+	var toRev int
+	var maxChars int
+	var maxTokens int
+	var offset int
+
 	cmd := &cobra.Command{
 		Use:   "entry <id> [id...]",
 		Short: "Get full entry content (supports multiple IDs)",
@@ -118,26 +160,68 @@ func newGetEntryCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Co
 				ids = append(ids, id)
 			}
 
-			if getOutput() == OutputJSON {
-				entries := make([]any, 0, len(ids))
-				for _, id := range ids {
-					entry, err := app.store.GetEntry(cmd.Context(), id)
+			if revisions && diff {
+				return fmt.Errorf("%w: --revisions and --diff are mutually exclusive", store.ErrInvalidInput)
+			}
+			if (full || localMedia) && (revisions || diff) {
+				return fmt.Errorf("%w: --full and --local-media cannot be combined with --revisions or --diff", store.ErrInvalidInput)
+			}
+			if !diff && (cmd.Flags().Changed("from") || cmd.Flags().Changed("to")) {
+				return fmt.Errorf("%w: --from and --to require --diff", store.ErrInvalidInput)
+			}
+			budget, err := outputBudget(maxChars, maxTokens)
+			if err != nil {
+				return err
+			}
+			if offset < 0 {
+				return fmt.Errorf("%w: --offset must be positive", store.ErrInvalidInput)
+			}
+			if offset > 0 && len(ids) > 1 {
+				return fmt.Errorf("%w: --offset takes a single entry ID", store.ErrInvalidInput)
+			}
+			if (budget > 0 || offset > 0) && (revisions || diff) {
+				return fmt.Errorf("%w: --max-chars, --max-tokens and --offset cannot be combined with --revisions or --diff", store.ErrInvalidInput)
+			}
+			if revisions {
+				return printEntryRevisions(cmd, app, ids, getOutput())
+			}
+			if diff {
+				return printEntryDiffs(cmd, app, ids, fromRev, toRev, getOutput())
+			}
+
+			entries := make([]Entry, 0, len(ids))
+			for _, id := range ids {
+				entry, err := app.store.GetEntry(cmd.Context(), id)
+				if err != nil {
+					return fmt.Errorf("get entry %d: %w", id, err)
+				}
+				if full && entry.FullContentMD == "" {
+					updated, err := refetchEntryContent(cmd.Context(), app, entry)
 					if err != nil {
-						return fmt.Errorf("get entry %d: %w", id, err)
+						fmt.Fprintf(os.Stderr, "warning: entry %d: full article unavailable, showing feed content: %v\n", id, err)
+					} else {
+						entry = updated
+					}
+				}
+				if localMedia {
+					if entry, err = localizeEntryMedia(cmd, app, entry); err != nil {
+						return fmt.Errorf("get entry %d media: %w", id, err)
 					}
-					entries = append(entries, entry)
 				}
+				entries = append(entries, entry)
+			}
+			if budget > 0 || offset > 0 {
+				budgetContents(entries, budget, offset)
+			}
+
+			if getOutput() == OutputJSON {
 				return writeJSON(os.Stdout, entries)
 			}
 
-			for i, id := range ids {
+			for i, entry := range entries {
 				if i > 0 {
 					fmt.Fprintf(os.Stdout, "\n---\n\n")
 				}
-				entry, err := app.store.GetEntry(cmd.Context(), id)
-				if err != nil {
-					return fmt.Errorf("get entry %d: %w", id, err)
-				}
 
 				title := strings.TrimSpace(entry.Title)
 				if title == "" {
@@ -146,9 +230,20 @@ func newGetEntryCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Co
 				date := formatDate(entry.PublishedAt)
 				url := fallback(entry.URL, "-")
 				fmt.Fprintf(os.Stdout, "# %s\n", title)
-				fmt.Fprintf(os.Stdout, "source: %s | date: %s | url: %s\n\n", entry.FeedTitle, date, url)
+				fmt.Fprintf(os.Stdout, "source: %s | date: %s | url: %s", entry.FeedTitle, date, url)
+				if len(entry.Tags) > 0 {
+					fmt.Fprintf(os.Stdout, " | tags: %s", strings.Join(entry.Tags, ", "))
+				}
+				fmt.Fprint(os.Stdout, "\n")
+				for _, enc := range entry.Enclosures {
+					fmt.Fprintf(os.Stdout, "enclosure: %s (%s, %s, %s)\n", enc.URL, fallback(enc.MimeType, "unknown type"), formatBytes(enc.Length), formatClock(enc.DurationSeconds))
+				}
+				fmt.Fprint(os.Stdout, "\n")
 
-				content := strings.TrimSpace(entry.ContentMD)
+				content := strings.TrimSpace(entry.FullContentMD)
+				if content == "" {
+					content = strings.TrimSpace(entry.ContentMD)
+				}
 				if content == "" {
 					content = strings.TrimSpace(entry.Summary)
 				}
@@ -156,14 +251,134 @@ func newGetEntryCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Co
 					content = url
 				}
 				fmt.Fprintln(os.Stdout, content)
+				if note := truncationNote(entry); note != "" {
+					fmt.Fprintf(os.Stdout, "\n%s\n", note)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&full, "full", false, "Download and extract the full article from the entry's web page if not stored yet")
+	cmd.Flags().BoolVar(&localMedia, "local-media", false, "Point image and enclosure links at local copies saved by feed download")
+	cmd.Flags().BoolVar(&revisions, "revisions", false, "List stored content revisions instead of the content")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Show a unified diff of the Markdown between two revisions")
+	cmd.Flags().IntVar(&fromRev, "from", 0, "Revision to diff from (default: the one before --to)")
+	cmd.Flags().IntVar(&toRev, "to", 0, "Revision to diff to (default: current)")
+	addBudgetFlags(cmd, &maxChars, &maxTokens)
+	cmd.Flags().IntVar(&offset, "offset", 0, "Skip this many characters of content, to continue a truncated entry")
+	return cmd
+}
+
+func printEntryRevisions(cmd *cobra.Command, app *App, ids []int64, output OutputFormat) error {
+	all := make([]EntryRevision, 0, len(ids))
+	for _, id := range ids {
+		revs, err := app.store.ListEntryRevisions(cmd.Context(), id)
+		if err != nil {
+			return fmt.Errorf("get entry %d revisions: %w", id, err)
+		}
+		all = append(all, revs...)
+	}
+	if output == OutputJSON {
+		return writeJSON(os.Stdout, all)
+	}
+	writeRevisionsTable(os.Stdout, all)
+	return nil
+}
+
+func printEntryDiffs(cmd *cobra.Command, app *App, ids []int64, fromRev, toRev int, output OutputFormat) error {
+	diffs := make([]EntryDiffResponse, 0, len(ids))
+	for _, id := range ids {
+		revs, err := app.store.ListEntryRevisions(cmd.Context(), id)
+		if err != nil {
+			return fmt.Errorf("get entry %d revisions: %w", id, err)
+		}
+		to := toRev
+		if to == 0 {
+			to = len(revs)
+		}
+		from := fromRev
+		if from == 0 {
+			from = to - 1
+		}
+		if from < 1 || to < 1 || from > len(revs) || to > len(revs) {
+			if len(revs) == 1 {
+				return fmt.Errorf("%w: entry %d has no earlier revisions", store.ErrInvalidInput, id)
+			}
+			return fmt.Errorf("%w: entry %d has revisions 1-%d", store.ErrInvalidInput, id, len(revs))
+		}
+		a, b := revs[from-1], revs[to-1]
+		diffs = append(diffs, EntryDiffResponse{
+			EntryID: id,
+			From:    from,
+			To:      to,
+			Diff:    unifiedDiff(revisionLabel(a), revisionLabel(b), a.ContentMD, b.ContentMD),
+		})
+	}
+	if output == OutputJSON {
+		return writeJSON(os.Stdout, diffs)
+	}
+	for i, d := range diffs {
+		if i > 0 {
+			fmt.Fprintf(os.Stdout, "\n---\n\n")
+		}
+		if d.Diff == "" {
+			fmt.Fprintf(os.Stdout, "Entry %d: no Markdown changes between revisions %d and %d\n", d.EntryID, d.From, d.To)
+			continue
+		}
+		fmt.Fprint(os.Stdout, d.Diff)
+	}
+	return nil
+}
+
+func revisionLabel(rev EntryRevision) string {
+	label := fmt.Sprintf("entry %d revision %d", rev.EntryID, rev.Revision)
+	if rev.Current {
+		label += " (current)"
+	}
+	return label + "\t" + rev.FetchedAt.UTC().Format(time.RFC3339)
+}
+
+func newGetEnclosuresCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var mimeType string
+	var feedID int64
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "enclosures",
+		Short: "List media attachments (podcast episodes, videos), newest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+			items, err := app.store.ListEnclosures(cmd.Context(), EnclosureListOptions{
+				MimeType: mimeType,
+				FeedID:   feedID,
+				Limit:    limit,
+			})
+			if err != nil {
+				return fmt.Errorf("list enclosures: %w", err)
+			}
+			switch getOutput() {
+			case OutputJSON:
+				return writeJSON(os.Stdout, items)
+			case OutputWide:
+				writeEnclosuresTable(os.Stdout, items, true)
+			default:
+				writeEnclosuresTable(os.Stdout, items, false)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&mimeType, "type", "", "Filter by MIME type: exact (audio/mpeg) or major type (audio, video/*)")
+	cmd.Flags().Int64Var(&feedID, "feed", 0, "Filter by feed ID")
+	cmd.Flags().IntVar(&limit, "limit", 50, "Result limit")
 	return cmd
 }
 
 func newGetFeedsCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var folder string
+
 	cmd := &cobra.Command{
 		Use:   "feeds",
 		Short: "List subscribed feeds",
@@ -172,7 +387,7 @@ func newGetFeedsCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Co
 			if err != nil {
 				return err
 			}
-			feeds, err := app.store.ListFeedsWithCounts(cmd.Context())
+			feeds, err := app.store.ListFeedsWithCounts(cmd.Context(), FeedListOptions{Folder: folder})
 			if err != nil {
 				return fmt.Errorf("list feeds: %w", err)
 			}
@@ -187,6 +402,7 @@ func newGetFeedsCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Co
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&folder, "folder", "", "Filter by folder name")
 	return cmd
 }
 
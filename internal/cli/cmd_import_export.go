@@ -13,6 +13,7 @@ type ImportResult struct {
 	InputURL      string `json:"input_url"`
 	NormalizedURL string `json:"normalized_url,omitempty"`
 	FeedID        int64  `json:"feed_id,omitempty"`
+	Folder        string `json:"folder,omitempty"`
 	Added         bool   `json:"added"`
 	Error         string `json:"error,omitempty"`
 }
@@ -36,15 +37,15 @@ func newImportCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comm
 			if err != nil {
 				return err
 			}
-			urls, err := opml.ReadOPML(args[0])
+			subs, err := opml.ReadOPMLSubscriptions(args[0])
 			if err != nil {
 				return fmt.Errorf("read opml: %w", err)
 			}
-			report := ImportReport{File: args[0], Total: len(urls), Results: make([]ImportResult, 0, len(urls))}
+			report := ImportReport{File: args[0], Total: len(subs), Results: make([]ImportResult, 0, len(subs))}
 
-			for _, u := range urls {
-				item := ImportResult{InputURL: u}
-				normalized, normalizeErr := feedpkg.NormalizeURL(u)
+			for _, sub := range subs {
+				item := ImportResult{InputURL: sub.URL}
+				normalized, normalizeErr := feedpkg.NormalizeURL(sub.URL)
 				if normalizeErr != nil {
 					item.Error = normalizeErr.Error()
 					report.Failed++
@@ -62,6 +63,17 @@ func newImportCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comm
 				}
 				item.Added = added
 				item.FeedID = feed.ID
+				item.Folder = feed.Folder
+				// Keep an existing feed's folder; only file feeds that are new or unfiled.
+				if sub.Folder != "" && (added || feed.Folder == "") {
+					if folderErr := app.store.SetFeedFolder(cmd.Context(), feed.ID, sub.Folder); folderErr != nil {
+						item.Error = folderErr.Error()
+						report.Failed++
+						report.Results = append(report.Results, item)
+						continue
+					}
+					item.Folder = sub.Folder
+				}
 				if added {
 					report.Added++
 				} else {
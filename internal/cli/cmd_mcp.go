@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/odysseus0/feed/internal/mcp"
+)
+
+func newMCPCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the database to agents over MCP (Model Context Protocol) on stdio",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+			srv := mcp.New(app.store, app.fetcher, app.cfg, Version)
+			return srv.Serve(cmd.Context(), os.Stdin, os.Stdout)
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+func newRefetchCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refetch",
+		Short: "Download content again from the source",
+	}
+	cmd.AddCommand(newRefetchEntryCmd(getApp, getOutput))
+	return cmd
+}
+
+func newRefetchEntryCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	return &cobra.Command{
+		Use:   "entry <id> [id...]",
+		Short: "Download an entry's web page and store the extracted full article",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+
+			ids := make([]int64, 0, len(args))
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return fmt.Errorf("%w: %v", store.ErrInvalidInput, err)
+				}
+				ids = append(ids, id)
+			}
+
+			entries := make([]Entry, 0, len(ids))
+			for _, id := range ids {
+				entry, err := app.store.GetEntry(cmd.Context(), id)
+				if err != nil {
+					return fmt.Errorf("get entry %d: %w", id, err)
+				}
+				entry, err = refetchEntryContent(cmd.Context(), app, entry)
+				if err != nil {
+					return fmt.Errorf("refetch entry %d: %w", id, err)
+				}
+				entries = append(entries, entry)
+			}
+
+			if getOutput() == OutputJSON {
+				return writeJSON(os.Stdout, entries)
+			}
+			for _, entry := range entries {
+				fmt.Fprintf(os.Stdout, "Refetched entry %d: %s (%d chars)\n", entry.ID, fallback(entry.Title, entry.URL), len(entry.FullContentMD))
+			}
+			return nil
+		},
+	}
+}
+
+// refetchEntryContent downloads the entry's page, stores the extracted
+// article next to the feed-provided content and returns the updated entry.
+func refetchEntryContent(ctx context.Context, app *App, entry Entry) (Entry, error) {
+	if strings.TrimSpace(entry.URL) == "" {
+		return Entry{}, fmt.Errorf("%w: entry %d has no URL", store.ErrInvalidInput, entry.ID)
+	}
+	contentHTML, contentMD, err := app.fetcher.FetchArticle(ctx, entry.URL)
+	if err != nil {
+		return Entry{}, fmt.Errorf("fetch article: %w", err)
+	}
+	if err := app.store.SetEntryFullContent(ctx, entry.ID, contentHTML, contentMD, time.Now()); err != nil {
+		return Entry{}, err
+	}
+	return app.store.GetEntry(ctx, entry.ID)
+}
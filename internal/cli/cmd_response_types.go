@@ -1,14 +1,5 @@
 package cli
 
-import "github.com/odysseus0/feed/internal/model"
-
-type AddFeedResponse struct {
-	Feed          model.Feed        `json:"feed"`
-	Inserted      bool              `json:"inserted"`
-	DiscoveredURL string            `json:"discovered_url"`
-	FetchReport   model.FetchReport `json:"fetch_report"`
-}
-
 type RemoveFeedResponse struct {
 	RemovedFeedID int64 `json:"removed_feed_id"`
 }
@@ -19,9 +10,9 @@ type UpdateEntryResponse struct {
 	Starred *bool `json:"starred,omitempty"`
 }
 
-type BatchUpdateEntriesResponse struct {
-	Updated int     `json:"updated"`
-	IDs     []int64 `json:"ids"`
-	Read    *bool   `json:"read,omitempty"`
-	Starred *bool   `json:"starred,omitempty"`
+type EntryDiffResponse struct {
+	EntryID int64  `json:"entry_id"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	Diff    string `json:"diff"`
 }
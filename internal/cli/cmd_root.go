@@ -76,6 +76,10 @@ func NewRootCmd(cfg config.Config) *cobra.Command {
 	cmd.AddCommand(newRemoveCmd(getApp, getOutput))
 	cmd.AddCommand(newUpdateCmd(getApp, getOutput))
 	cmd.AddCommand(newFetchCmd(getApp, getOutput))
+	cmd.AddCommand(newRefetchCmd(getApp, getOutput))
+	cmd.AddCommand(newDownloadCmd(getApp, getOutput))
+	cmd.AddCommand(newServeCmd(getApp, getOutput))
+	cmd.AddCommand(newMCPCmd(getApp, getOutput))
 	cmd.AddCommand(newImportCmd(getApp, getOutput))
 	cmd.AddCommand(newExportCmd(getApp, getOutput))
 	cmd.AddCommand(newSearchCmd(getApp, getOutput))
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/odysseus0/feed/internal/server"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// serveShutdownTimeout bounds how long in-flight requests may run after an
+// interrupt.
+const serveShutdownTimeout = 10 * time.Second
+
+func newServeCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var addr string
+	var allowHosts []string
+	var noJSONAPI bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the database as a local HTTP JSON API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+			if !noJSONAPI && !server.LoopbackAddr(addr) && app.cfg.APIToken == "" {
+				return fmt.Errorf("%w: set api_token (FEED_API_TOKEN) or pass --no-json-api to listen beyond loopback on %s", store.ErrInvalidInput, addr)
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("listen on %s: %w", addr, err)
+			}
+			srv := &http.Server{
+				Handler:           server.New(app.store, app.fetcher, app.cfg, server.Options{Addr: addr, AllowedHosts: allowHosts, NoJSONAPI: noJSONAPI}),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			errc := make(chan error, 1)
+			go func() { errc <- srv.Serve(ln) }()
+			if !noJSONAPI {
+				fmt.Fprintf(os.Stderr, "JSON API on http://%s/v1/\n", ln.Addr())
+			}
+			if app.cfg.FeverAPIKey != "" {
+				fmt.Fprintf(os.Stderr, "Fever API on http://%s/fever/\n", ln.Addr())
+			}
+			if app.cfg.GReaderUsername != "" {
+				fmt.Fprintf(os.Stderr, "Google Reader API on http://%s/\n", ln.Addr())
+			}
+			fmt.Fprintln(os.Stderr, "Serving (Ctrl-C to stop)")
+
+			select {
+			case err := <-errc:
+				return fmt.Errorf("serve: %w", err)
+			case <-ctx.Done():
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("shut down server: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:7070", "Address to listen on")
+	cmd.Flags().BoolVar(&noJSONAPI, "no-json-api", false, "Serve only the Fever and Google Reader APIs, not /v1")
+	cmd.Flags().StringSliceVar(&allowHosts, "allow-host", nil, "Also accept requests addressed to this host name (repeatable)")
+	return cmd
+}
@@ -3,9 +3,12 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/odysseus0/feed/internal/store"
+	"github.com/odysseus0/feed/internal/timerange"
 )
 
 func newUpdateCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
@@ -15,6 +18,118 @@ func newUpdateCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Comm
 	}
 	cmd.AddCommand(newUpdateEntryCmd(getApp, getOutput))
 	cmd.AddCommand(newUpdateEntriesCmd(getApp, getOutput))
+	cmd.AddCommand(newUpdateFeedCmd(getApp, getOutput))
+	return cmd
+}
+
+func newUpdateFeedCmd(getApp func() *App, getOutput func() OutputFormat) *cobra.Command {
+	var title string
+	var clearTitle bool
+	var pause bool
+	var resume bool
+	var interval string
+	var newURL string
+	var fullContent bool
+	var noFullContent bool
+	var autoDownload bool
+	var noAutoDownload bool
+
+	cmd := &cobra.Command{
+		Use:   "feed <id>",
+		Short: "Update feed settings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := requireApp(getApp)
+			if err != nil {
+				return err
+			}
+
+			id, err := parseID(args[0])
+			if err != nil {
+				return fmt.Errorf("%w: %v", store.ErrInvalidInput, err)
+			}
+			if pause && resume {
+				return fmt.Errorf("%w: choose at most one of --pause, --resume", store.ErrInvalidInput)
+			}
+			if clearTitle && cmd.Flags().Changed("title") {
+				return fmt.Errorf("%w: choose at most one of --title, --clear-title", store.ErrInvalidInput)
+			}
+			if fullContent && noFullContent {
+				return fmt.Errorf("%w: choose at most one of --full-content, --no-full-content", store.ErrInvalidInput)
+			}
+			if autoDownload && noAutoDownload {
+				return fmt.Errorf("%w: choose at most one of --auto-download, --no-auto-download", store.ErrInvalidInput)
+			}
+
+			var in UpdateFeedInput
+			if cmd.Flags().Changed("title") {
+				in.CustomTitle = &title
+			}
+			if clearTitle {
+				empty := ""
+				in.CustomTitle = &empty
+			}
+			if pause || resume {
+				in.Paused = &pause
+			}
+			if fullContent || noFullContent {
+				in.FullContent = &fullContent
+			}
+			if autoDownload || noAutoDownload {
+				in.AutoDownload = &autoDownload
+			}
+			if cmd.Flags().Changed("interval") {
+				d, ok := timerange.ParseDuration(interval)
+				if !ok || d < 0 {
+					return fmt.Errorf("%w: invalid interval %q (expected a duration like 30m, 6h or 1d; 0 to reset)", store.ErrInvalidInput, interval)
+				}
+				minutes := int(d / time.Minute)
+				if d > 0 && minutes == 0 {
+					minutes = 1
+				}
+				in.FetchIntervalMinutes = &minutes
+			}
+			changedURL := cmd.Flags().Changed("url")
+			if in == (UpdateFeedInput{}) && !changedURL {
+				return fmt.Errorf("%w: nothing to update (use --url, --title, --clear-title, --pause, --resume, --interval, --full-content, --no-full-content, --auto-download, --no-auto-download)", store.ErrInvalidInput)
+			}
+
+			if changedURL {
+				if _, err := app.store.GetFeedByID(cmd.Context(), id); err != nil {
+					return fmt.Errorf("update feed: %w", err)
+				}
+				discovered, err := app.fetcher.DiscoverFeedURL(cmd.Context(), newURL)
+				if err != nil {
+					return fmt.Errorf("discover feed url: %w", err)
+				}
+				if discovered != newURL {
+					fmt.Fprintf(os.Stderr, "Discovered feed URL: %s\n", discovered)
+				}
+				in.URL = &discovered
+			}
+			feed, err := app.store.UpdateFeed(cmd.Context(), id, in)
+			if err != nil {
+				return fmt.Errorf("update feed: %w", err)
+			}
+
+			if getOutput() == OutputJSON {
+				return writeJSON(os.Stdout, feed)
+			}
+			fmt.Fprintf(os.Stdout, "Updated feed %d: %s\n", feed.ID, fallback(feed.Title, feed.URL))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&newURL, "url", "", "Move the feed to a new URL (auto-discovered), keeping its entries")
+	cmd.Flags().StringVar(&title, "title", "", "Set a custom title that overrides the feed's own title")
+	cmd.Flags().BoolVar(&clearTitle, "clear-title", false, "Remove the custom title")
+	cmd.Flags().BoolVar(&pause, "pause", false, "Stop fetching this feed")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Resume fetching this feed")
+	cmd.Flags().StringVar(&interval, "interval", "", "Minimum time between fetches, e.g. 6h or 1d (0 resets to every fetch)")
+	cmd.Flags().BoolVar(&fullContent, "full-content", false, "Download each entry's web page and extract the full article")
+	cmd.Flags().BoolVar(&noFullContent, "no-full-content", false, "Stop extracting full articles for this feed")
+	cmd.Flags().BoolVar(&autoDownload, "auto-download", false, "Download enclosures and images of new entries on each feed fetch run")
+	cmd.Flags().BoolVar(&noAutoDownload, "no-auto-download", false, "Stop downloading media for this feed")
 	return cmd
 }
 
@@ -112,6 +227,8 @@ func newUpdateEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cob
 	var markUnread bool
 	var markStarred bool
 	var markUnstarred bool
+	var addTags []string
+	var removeTags []string
 
 	cmd := &cobra.Command{
 		Use:   "entries [id] [id...]",
@@ -135,8 +252,14 @@ func newUpdateEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cob
 			if markUnstarred {
 				selected++
 			}
+			if len(addTags) > 0 {
+				selected++
+			}
+			if len(removeTags) > 0 {
+				selected++
+			}
 			if selected != 1 {
-				return fmt.Errorf("%w: choose exactly one of --read, --unread, --starred, --unstarred", store.ErrInvalidInput)
+				return fmt.Errorf("%w: choose exactly one of --read, --unread, --starred, --unstarred, --tag, --untag", store.ErrInvalidInput)
 			}
 
 			ids, err := parseIDs(args)
@@ -144,6 +267,36 @@ func newUpdateEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cob
 				return fmt.Errorf("%w: %v", store.ErrInvalidInput, err)
 			}
 
+			if len(addTags) > 0 {
+				if err := app.store.AddEntryTags(cmd.Context(), ids, addTags); err != nil {
+					return fmt.Errorf("tag entries: %w", err)
+				}
+				if getOutput() == OutputJSON {
+					return writeJSON(os.Stdout, BatchUpdateEntriesResponse{
+						Updated:   len(ids),
+						IDs:       ids,
+						TagsAdded: addTags,
+					})
+				}
+				fmt.Fprintf(os.Stdout, "Tagged %d entries with %s\n", len(ids), strings.Join(addTags, ", "))
+				return nil
+			}
+
+			if len(removeTags) > 0 {
+				if err := app.store.RemoveEntryTags(cmd.Context(), ids, removeTags); err != nil {
+					return fmt.Errorf("untag entries: %w", err)
+				}
+				if getOutput() == OutputJSON {
+					return writeJSON(os.Stdout, BatchUpdateEntriesResponse{
+						Updated:     len(ids),
+						IDs:         ids,
+						TagsRemoved: removeTags,
+					})
+				}
+				fmt.Fprintf(os.Stdout, "Removed %s from %d entries\n", strings.Join(removeTags, ", "), len(ids))
+				return nil
+			}
+
 			if markRead {
 				if err := app.store.SetEntriesRead(cmd.Context(), ids, true); err != nil {
 					return fmt.Errorf("update entries read: %w", err)
@@ -212,6 +365,8 @@ func newUpdateEntriesCmd(getApp func() *App, getOutput func() OutputFormat) *cob
 	cmd.Flags().BoolVar(&markUnread, "unread", false, "Mark all provided IDs as unread")
 	cmd.Flags().BoolVar(&markStarred, "starred", false, "Mark all provided IDs as starred")
 	cmd.Flags().BoolVar(&markUnstarred, "unstarred", false, "Mark all provided IDs as unstarred")
+	cmd.Flags().StringSliceVar(&addTags, "tag", nil, "Add tag(s) to all provided IDs")
+	cmd.Flags().StringSliceVar(&removeTags, "untag", nil, "Remove tag(s) from all provided IDs")
 	return cmd
 }
 
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -23,6 +25,30 @@ func runCLI(t *testing.T, cfgPath string, args ...string) {
 	}
 }
 
+// runCLIOutput runs the command like runCLI and returns what it wrote to
+// stdout.
+func runCLIOutput(t *testing.T, cfgPath string, args ...string) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	out := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+		_ = w.Close()
+	}()
+	runCLI(t, cfgPath, args...)
+	_ = w.Close()
+	return string(<-out)
+}
+
 func TestCLICommandFlowSmoke(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "feed.db")
 
@@ -41,7 +67,7 @@ func TestCLICommandFlowSmoke(t *testing.T) {
 		switch r.URL.Path {
 		case "/":
 			_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`))
-		case "/feed.xml":
+		case "/feed.xml", "/blog.xml":
 			w.Header().Set("Content-Type", "application/rss+xml")
 			_, _ = w.Write([]byte(feedXML))
 		default:
@@ -72,6 +98,14 @@ func TestCLICommandFlowSmoke(t *testing.T) {
 	_ = db.Close()
 
 	runCLI(t, dbPath, "get", "entry", fmt.Sprintf("%d", entryID))
+	runCLI(t, dbPath, "get", "entry", fmt.Sprintf("%d", entryID), "--revisions")
+	runCLI(t, dbPath, "get", "entry", fmt.Sprintf("%d", entryID), "--max-chars", "5")
+	runCLI(t, dbPath, "get", "entry", fmt.Sprintf("%d", entryID), "--offset", "5", "-o", "json")
+	runCLI(t, dbPath, "get", "entries", "--status=all", "--no-fetch", "--max-tokens", "2")
+	runCLI(t, dbPath, "search", "Entry", "--max-chars", "3", "-o", "json")
+	runCLI(t, dbPath, "get", "enclosures", "--type", "audio")
+	runCLI(t, dbPath, "download", fmt.Sprintf("%d", entryID))
+	runCLI(t, dbPath, "get", "entry", fmt.Sprintf("%d", entryID), "--local-media")
 	runCLI(t, dbPath, "update", "entry", fmt.Sprintf("%d", entryID), "--read")
 	runCLI(t, dbPath, "update", "entry", fmt.Sprintf("%d", entryID), "--unread")
 	runCLI(t, dbPath, "update", "entries", fmt.Sprintf("%d", entryID), "--starred")
@@ -82,12 +116,45 @@ func TestCLICommandFlowSmoke(t *testing.T) {
 <opml version="2.0">
   <body>
     <outline text="A" xmlUrl="`+srv.URL+`/feed.xml" />
+    <outline text="Blogs">
+      <outline text="B" xmlUrl="`+srv.URL+`/blog.xml" />
+    </outline>
     <outline text="Bad" xmlUrl="  " />
   </body>
 </opml>`), 0o644); err != nil {
 		t.Fatalf("write opml: %v", err)
 	}
 	runCLI(t, dbPath, "import", opmlFile, "-o", "wide")
+	runCLI(t, dbPath, "get", "feeds", "--folder", "Blogs", "-o", "wide")
+
+	// The top-level outline stays unfiled; only the nested one is in Blogs.
+	var feeds []Feed
+	if err := json.Unmarshal([]byte(runCLIOutput(t, dbPath, "get", "feeds", "-o", "json")), &feeds); err != nil {
+		t.Fatalf("decode feeds: %v", err)
+	}
+	folders := map[string]string{}
+	for _, f := range feeds {
+		folders[f.URL] = f.Folder
+	}
+	if len(feeds) != 2 || folders[srv.URL+"/feed.xml"] != "" || folders[srv.URL+"/blog.xml"] != "Blogs" {
+		t.Fatalf("unexpected folders after import: %v", folders)
+	}
+	var blogs []Feed
+	if err := json.Unmarshal([]byte(runCLIOutput(t, dbPath, "get", "feeds", "--folder", "Blogs", "-o", "json")), &blogs); err != nil {
+		t.Fatalf("decode folder feeds: %v", err)
+	}
+	if len(blogs) != 1 || blogs[0].URL != srv.URL+"/blog.xml" {
+		t.Fatalf("expected only the nested feed in Blogs, got %+v", blogs)
+	}
+
+	runCLI(t, dbPath, "fetch", "--folder", "Blogs")
+	var inFolder []Entry
+	if err := json.Unmarshal([]byte(runCLIOutput(t, dbPath, "get", "entries", "--folder", "Blogs", "--status=all", "--no-fetch", "-o", "json")), &inFolder); err != nil {
+		t.Fatalf("decode folder entries: %v", err)
+	}
+	if len(inFolder) != 1 || inFolder[0].FeedID != blogs[0].ID {
+		t.Fatalf("expected the nested feed's entry only, got %+v", inFolder)
+	}
 	runCLI(t, dbPath, "export")
 }
 
@@ -115,5 +182,7 @@ func testConfig(dbPath string) config.Config {
 		FetchConcurrency: 2,
 		HTTPTimeout:      10 * time.Second,
 		UserAgent:        "feed-test/1.0",
+		MediaMaxFileSize: 1 << 20,
+		MediaCacheSize:   8 << 20,
 	}
 }
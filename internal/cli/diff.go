@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	// aLine and bLine count the lines of each side consumed before this op.
+	aLine, bLine int
+}
+
+// unifiedDiff returns a unified diff turning a into b, or "" when the two
+// texts have the same lines.
+func unifiedDiff(fromName, toName, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		first := nextChange(ops, i)
+		if first < 0 {
+			break
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		start := max(first-diffContextLines, i)
+		end := first + 1
+		for {
+			next := nextChange(ops, end)
+			if next < 0 || next-end > 2*diffContextLines {
+				break
+			}
+			end = next + 1
+		}
+		end = min(end+diffContextLines, len(ops))
+
+		aLen, bLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(ops[start].aLine, aLen), hunkRange(ops[start].bLine, bLen))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func nextChange(ops []diffOp, from int) int {
+	for i := from; i < len(ops); i++ {
+		if ops[i].kind != ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+func hunkRange(before, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	default:
+		return fmt.Sprintf("%d,%d", before+1, n)
+	}
+}
+
+func splitLines(v string) []string {
+	v = strings.TrimRight(v, "\n")
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, "\n")
+}
+
+// diffLines computes a shortest line-level edit script with Myers'
+// linear-space algorithm, so large revisions need memory proportional to
+// their length rather than the product of both lengths.
+func diffLines(a, b []string) []diffOp {
+	d := differ{a: a, b: b, ops: make([]diffOp, 0, len(a)+len(b))}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+type differ struct {
+	a, b []string
+	ops  []diffOp
+}
+
+func (d *differ) emit(kind byte, i, j int) {
+	text := ""
+	if kind == '+' {
+		text = d.b[j]
+	} else {
+		text = d.a[i]
+	}
+	d.ops = append(d.ops, diffOp{kind: kind, text: text, aLine: i, bLine: j})
+}
+
+// compare appends the edit script turning a[a0:a1] into b[b0:b1]. Shared
+// leading and trailing lines are matched directly, and what differs in
+// between is split at a middle snake and compared in halves.
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.emit(' ', a0, b0)
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	switch {
+	case a0 == a1:
+		for j := b0; j < b1; j++ {
+			d.emit('+', a0, j)
+		}
+	case b0 == b1:
+		for i := a0; i < a1; i++ {
+			d.emit('-', i, b0)
+		}
+	default:
+		if x, y, ok := d.middleSnake(a0, a1, b0, b1); ok {
+			d.compare(a0, x, b0, y)
+			d.compare(x, a1, y, b1)
+		} else {
+			for i := a0; i < a1; i++ {
+				d.emit('-', i, b0)
+			}
+			for j := b0; j < b1; j++ {
+				d.emit('+', a1, j)
+			}
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		d.emit(' ', a1+k, b1+k)
+	}
+}
+
+// middleSnake runs the forward and reverse searches of Myers' algorithm
+// until their paths overlap and returns the point where they meet. It
+// reports false when the ranges share no lines at all.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x, y int, ok bool) {
+	n, m := a1-a0, b1-b0
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+1)
+	reverse := make([]int, 2*maxD+1)
+	for i := range forward {
+		forward[i], reverse[i] = -1, -1
+	}
+	forward[offset+1], reverse[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the forward path finds the overlap, else the
+	// reverse one does.
+	odd := delta%2 != 0
+
+	var fStart, fEnd, rStart, rEnd int
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			ki := offset + k
+			var x1 int
+			if k == -step || (k != step && forward[ki-1] < forward[ki+1]) {
+				x1 = forward[ki+1]
+			} else {
+				x1 = forward[ki-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && d.a[a0+x1] == d.b[b0+y1] {
+				x1++
+				y1++
+			}
+			forward[ki] = x1
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case odd:
+				ri := offset + delta - k
+				if ri >= 0 && ri < len(reverse) && reverse[ri] != -1 && x1 >= n-reverse[ri] {
+					return a0 + x1, b0 + y1, true
+				}
+			}
+		}
+		for k := -step + rStart; k <= step-rEnd; k += 2 {
+			ki := offset + k
+			var x2 int
+			if k == -step || (k != step && reverse[ki-1] < reverse[ki+1]) {
+				x2 = reverse[ki+1]
+			} else {
+				x2 = reverse[ki-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && d.a[a1-x2-1] == d.b[b1-y2-1] {
+				x2++
+				y2++
+			}
+			reverse[ki] = x2
+			switch {
+			case x2 > n:
+				rEnd += 2
+			case y2 > m:
+				rStart += 2
+			case !odd:
+				fi := offset + delta - k
+				if fi >= 0 && fi < len(forward) && forward[fi] != -1 {
+					x1 := forward[fi]
+					y1 := offset + x1 - fi
+					if x1 >= n-x2 {
+						return a0 + x1, b0 + y1, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
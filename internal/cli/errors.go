@@ -19,7 +19,7 @@ func ErrorExitCode(err error) int {
 	switch {
 	case err == nil:
 		return 0
-	case errors.Is(err, store.ErrInvalidInput):
+	case errors.Is(err, store.ErrInvalidInput), errors.Is(err, store.ErrConflict):
 		return exitInvalidInput
 	case errors.Is(err, store.ErrNotFound):
 		return exitNotFound
@@ -43,6 +43,8 @@ func FormatError(err error) string {
 		return fmt.Sprintf("Error [invalid-input]: %v", err)
 	case errors.Is(err, store.ErrNotFound):
 		return fmt.Sprintf("Error [not-found]: %v", err)
+	case errors.Is(err, store.ErrConflict):
+		return fmt.Sprintf("Error [conflict]: %v", err)
 	default:
 		msg := strings.ToLower(err.Error())
 		if strings.Contains(msg, "invalid id") || strings.Contains(msg, "invalid output format") ||
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"text/tabwriter"
 )
@@ -17,11 +18,11 @@ func writeJSON(out io.Writer, v any) error {
 func writeEntriesTable(out io.Writer, entries []Entry, wide bool) {
 	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
 	if wide {
-		fmt.Fprintln(tw, "ID\tFEED_ID\tFEED\tTITLE\tDATE\tREAD\tSTAR\tURL\tSUMMARY")
+		fmt.Fprintln(tw, "ID\tFEED_ID\tFEED\tTITLE\tDATE\tREAD\tSTAR\tTAGS\tURL\tSUMMARY")
 		for _, e := range entries {
 			fmt.Fprintf(
 				tw,
-				"%d\t%d\t%s\t%s\t%s\t%t\t%t\t%s\t%s\n",
+				"%d\t%d\t%s\t%s\t%s\t%t\t%t\t%s\t%s\t%s\n",
 				e.ID,
 				e.FeedID,
 				compactText(e.FeedTitle, 24),
@@ -29,8 +30,9 @@ func writeEntriesTable(out io.Writer, entries []Entry, wide bool) {
 				formatDate(e.PublishedAt),
 				e.Read,
 				e.Starred,
+				fallback(strings.Join(e.Tags, ","), "-"),
 				e.URL,
-				oneLine(e.Summary),
+				tableSummary(e),
 			)
 		}
 	} else {
@@ -44,7 +46,7 @@ func writeEntriesTable(out io.Writer, entries []Entry, wide bool) {
 				compactText(displayEntryTitle(e), 56),
 				formatDate(e.PublishedAt),
 				e.URL,
-				oneLine(e.Summary),
+				tableSummary(e),
 			)
 		}
 	}
@@ -54,16 +56,20 @@ func writeEntriesTable(out io.Writer, entries []Entry, wide bool) {
 func writeFeedsTable(out io.Writer, feeds []Feed, wide bool) {
 	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
 	if wide {
-		fmt.Fprintln(tw, "ID\tTITLE\tUNREAD\tTOTAL\tLAST_FETCH\tERRORS\tURL\tSITE_URL\tLAST_ERROR")
+		fmt.Fprintln(tw, "ID\tTITLE\tFOLDER\tUNREAD\tTOTAL\tLAST_FETCH\tNEXT_FETCH\tRETRY_AFTER\tPAUSED\tERRORS\tURL\tSITE_URL\tLAST_ERROR")
 		for _, f := range feeds {
 			fmt.Fprintf(
 				tw,
-				"%d\t%s\t%d\t%d\t%s\t%d\t%s\t%s\t%s\n",
+				"%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%t\t%d\t%s\t%s\t%s\n",
 				f.ID,
 				compactText(fallback(f.Title, f.URL), 30),
+				compactText(fallback(f.Folder, "-"), 20),
 				f.UnreadCount,
 				f.TotalCount,
 				humanAgo(f.LastFetchedAt),
+				humanUntil(f.NextFetchAt),
+				formatRetryAfter(f.RetryAfter),
+				f.Paused,
 				f.ErrorCount,
 				compactText(f.URL, 46),
 				compactText(f.SiteURL, 46),
@@ -117,6 +123,80 @@ func writeFetchReportTable(out io.Writer, rep FetchReport) {
 	_ = tw.Flush()
 }
 
+func writeRevisionsTable(out io.Writer, revs []EntryRevision) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENTRY\tREV\tFETCHED\tCHARS\tCURRENT\tTITLE")
+	for _, r := range revs {
+		fmt.Fprintf(
+			tw,
+			"%d\t%d\t%s\t%d\t%t\t%s\n",
+			r.EntryID,
+			r.Revision,
+			r.FetchedAt.Local().Format("2006-01-02 15:04"),
+			len(r.ContentMD),
+			r.Current,
+			compactText(r.Title, 56),
+		)
+	}
+	_ = tw.Flush()
+}
+
+func writeEnclosuresTable(out io.Writer, items []EntryEnclosure, wide bool) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if wide {
+		fmt.Fprintln(tw, "ENTRY\tFEED\tTITLE\tDATE\tTYPE\tSIZE\tDURATION\tURL")
+	} else {
+		fmt.Fprintln(tw, "ENTRY\tTITLE\tTYPE\tDURATION\tURL")
+	}
+	for _, item := range items {
+		title := compactText(fallback(item.EntryTitle, "(untitled)"), 56)
+		if wide {
+			fmt.Fprintf(
+				tw,
+				"%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				item.EntryID,
+				compactText(item.FeedTitle, 24),
+				title,
+				formatDate(item.PublishedAt),
+				fallback(item.MimeType, "-"),
+				formatBytes(item.Length),
+				formatClock(item.DurationSeconds),
+				item.URL,
+			)
+			continue
+		}
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%s\t%s\n",
+			item.EntryID,
+			title,
+			fallback(item.MimeType, "-"),
+			formatClock(item.DurationSeconds),
+			item.URL,
+		)
+	}
+	_ = tw.Flush()
+}
+
+// printNextPageHint tells the user how to fetch the next page when a listing
+// filled its limit. JSON consumers read the cursor from the last entry instead.
+func printNextPageHint(entries []Entry, limit int) {
+	if limit <= 0 || len(entries) < limit {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "More results available: --after %s\n", entries[len(entries)-1].Cursor)
+}
+
+// tableSummary flattens a summary for a table cell, marking one cut by
+// --max-chars or --max-tokens.
+func tableSummary(e Entry) string {
+	summary := oneLine(e.Summary)
+	if e.Truncated != nil {
+		summary += " [truncated]"
+	}
+	return summary
+}
+
 func oneLine(v string) string {
 	v = strings.ReplaceAll(v, "\n", " ")
 	v = strings.ReplaceAll(v, "\r", " ")
@@ -2,7 +2,10 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -130,3 +133,170 @@ func TestNotFoundMapsToStableErrorCodeAndMessage(t *testing.T) {
 		t.Fatalf("unexpected formatted error: %s", formatted)
 	}
 }
+
+func TestUpdateEntriesSupportsTagAndUntag(t *testing.T) {
+	dbPath := t.TempDir() + "/feed.db"
+	entryID := seedEntry(t, dbPath)
+	idArg := fmt.Sprintf("%d", entryID)
+
+	root := NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "update", "entries", idArg, "--tag", "to-read,security"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("tag: %v", err)
+	}
+	e := loadEntry(t, dbPath, entryID)
+	if len(e.Tags) != 2 {
+		t.Fatalf("expected 2 tags after --tag, got %#v", e.Tags)
+	}
+
+	root = NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "update", "entries", idArg, "--untag", "security"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("untag: %v", err)
+	}
+	e = loadEntry(t, dbPath, entryID)
+	if len(e.Tags) != 1 || e.Tags[0] != "to-read" {
+		t.Fatalf("expected only to-read after --untag, got %#v", e.Tags)
+	}
+}
+
+func TestUpdateFeedSetsTitlePauseAndInterval(t *testing.T) {
+	dbPath := t.TempDir() + "/feed.db"
+	entryID := seedEntry(t, dbPath)
+	feedID := loadEntry(t, dbPath, entryID).FeedID
+	idArg := fmt.Sprintf("%d", feedID)
+
+	root := NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "update", "feed", idArg, "--title", "Renamed", "--pause", "--interval", "1d"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	db, err := store.OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	feed, err := store.NewStore(db).GetFeedByID(context.Background(), feedID)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if feed.Title != "Renamed" || !feed.Paused || feed.FetchIntervalMinutes != 24*60 {
+		t.Fatalf("unexpected feed settings: %+v", feed)
+	}
+
+	root = NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "update", "feed", idArg})
+	if err := root.Execute(); ErrorExitCode(err) != exitInvalidInput {
+		t.Fatalf("expected invalid input without flags, got %v", err)
+	}
+}
+
+func TestUpdateFeedURLDiscoversAndKeepsEntries(t *testing.T) {
+	dbPath := t.TempDir() + "/feed.db"
+	entryID := seedEntry(t, dbPath)
+	feedID := loadEntry(t, dbPath, entryID).FeedID
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/rss.xml"></head></html>`))
+		case "/rss.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Moved</title></channel></rss>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	root := NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "update", "feed", fmt.Sprintf("%d", feedID), "--url", srv.URL})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("update feed url: %v", err)
+	}
+
+	e := loadEntry(t, dbPath, entryID)
+	if e.FeedID != feedID {
+		t.Fatalf("expected entry to stay on feed %d, got %d", feedID, e.FeedID)
+	}
+	db, err := store.OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	feed, err := store.NewStore(db).GetFeedByID(context.Background(), feedID)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if feed.URL != srv.URL+"/rss.xml" {
+		t.Fatalf("expected discovered url, got %q", feed.URL)
+	}
+}
+
+func TestRefetchEntryStoresFullArticle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><nav>Home | About</nav><article>
+<p>The complete article text lives only on the web page, not in the feed.</p>
+<p>It continues with a second paragraph that carries more of the story.</p>
+</article></body></html>`))
+	}))
+	defer srv.Close()
+
+	dbPath := t.TempDir() + "/feed.db"
+	db, err := store.OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	s := store.NewStore(db)
+	feed, _, err := s.CreateFeed(context.Background(), "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	entryID, _, err := s.UpsertEntry(context.Background(), model.UpsertEntryInput{
+		FeedID:  feed.ID,
+		GUID:    "excerpt-1",
+		Title:   "Excerpt",
+		URL:     srv.URL + "/post",
+		Summary: "Just the teaser.",
+	})
+	if err != nil {
+		t.Fatalf("upsert entry: %v", err)
+	}
+	_ = db.Close()
+
+	root := NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "refetch", "entry", fmt.Sprintf("%d", entryID)})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("refetch entry: %v", err)
+	}
+
+	e := loadEntry(t, dbPath, entryID)
+	if !strings.Contains(e.FullContentMD, "complete article text") {
+		t.Fatalf("expected extracted article, got %q", e.FullContentMD)
+	}
+	if strings.Contains(e.FullContentMD, "Home | About") {
+		t.Fatalf("expected navigation to be dropped, got %q", e.FullContentMD)
+	}
+	if e.Summary != "Just the teaser." {
+		t.Fatalf("expected feed content to be kept, got %q", e.Summary)
+	}
+}
+
+func TestRefetchEntryRequiresURL(t *testing.T) {
+	dbPath := t.TempDir() + "/feed.db"
+	entryID := seedEntry(t, dbPath)
+
+	root := NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "refetch", "entry", fmt.Sprintf("%d", entryID)})
+	err := root.Execute()
+	if !errors.Is(err, store.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for entry without URL, got %v", err)
+	}
+
+	root = NewRootCmd(testConfig(dbPath))
+	root.SetArgs([]string{"--db", dbPath, "get", "entry", fmt.Sprintf("%d", entryID), "--full"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("get entry --full should fall back to feed content: %v", err)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+	"github.com/odysseus0/feed/internal/timerange"
 )
 
 var wsRegexp = regexp.MustCompile(`\s+`)
@@ -18,6 +21,19 @@ func parseID(s string) (int64, error) {
 	return id, nil
 }
 
+func parseTimeRange(since, until string) (*time.Time, *time.Time, error) {
+	now := time.Now()
+	sinceT, err := timerange.Since(since, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: --since: %v", store.ErrInvalidInput, err)
+	}
+	untilT, err := timerange.Until(until, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: --until: %v", store.ErrInvalidInput, err)
+	}
+	return sinceT, untilT, nil
+}
+
 func formatDate(t *time.Time) string {
 	if t == nil || t.IsZero() {
 		return "-"
@@ -42,6 +58,61 @@ func humanAgo(t *time.Time) string {
 	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
 }
 
+func humanUntil(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "due"
+	}
+	d := time.Until(*t)
+	if d < time.Minute {
+		return "due"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("in %dm", int(d.Minutes()))
+	}
+	if d < 24*time.Hour {
+		return fmt.Sprintf("in %dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("in %dd", int(d.Hours()/24))
+}
+
+// formatRetryAfter shows a server-imposed "do not fetch before" time, or "-"
+// when none is pending.
+func formatRetryAfter(t *time.Time) string {
+	if t == nil || !time.Now().Before(*t) {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// formatClock renders a duration in seconds as H:MM:SS or M:SS, or "-".
+func formatClock(seconds int) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	h, m, sec := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
+
+// formatBytes renders a byte count with a binary unit, or "-" when unknown.
+func formatBytes(n int64) string {
+	if n <= 0 {
+		return "-"
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func compactText(v string, max int) string {
 	v = strings.TrimSpace(wsRegexp.ReplaceAllString(v, " "))
 	if max <= 0 || len(v) <= max {
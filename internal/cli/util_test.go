@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"fmt"
+	"math/rand"
+	"slices"
 	"testing"
 )
 
@@ -25,3 +28,108 @@ func TestFallback(t *testing.T) {
 		t.Fatalf("fallback empty: %q", got)
 	}
 }
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := unifiedDiff("a", "b", "same\ntext\n", "same\ntext"); got != "" {
+		t.Fatalf("expected no diff for equal text, got %q", got)
+	}
+
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+	b := "one\ntwo\nthree\nFOUR\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	want := `--- old
++++ new
+@@ -1,7 +1,7 @@
+ one
+ two
+ three
+-four
++FOUR
+ five
+ six
+ seven
+@@ -9,3 +9,4 @@
+ nine
+ ten
+ eleven
++twelve
+`
+	if got := unifiedDiff("old", "new", a, b); got != want {
+		t.Fatalf("unifiedDiff mismatch:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := unifiedDiff("old", "new", "", "added\n"); got != "--- old\n+++ new\n@@ -0,0 +1 @@\n+added\n" {
+		t.Fatalf("unifiedDiff from empty = %q", got)
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for trial := 0; trial < 2000; trial++ {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.text)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.text)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("diffLines(%q, %q) does not rebuild both sides: %+v", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) made %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesLargeRevisions(t *testing.T) {
+	a := make([]string, 10000)
+	b := make([]string, 10000)
+	for i := range a {
+		a[i] = fmt.Sprintf("line %d", i)
+		b[i] = fmt.Sprintf("line %d", i)
+		if i%100 == 50 {
+			b[i] = fmt.Sprintf("changed %d", i)
+		}
+	}
+	edits := 0
+	for _, op := range diffLines(a, b) {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != 200 {
+		t.Fatalf("expected 100 changed lines (200 edits), got %d", edits)
+	}
+}
+
+// lcsLength is the textbook quadratic LCS, as a reference for small inputs.
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -16,8 +16,14 @@ const (
 	defaultStaleMinutes    = 30
 	defaultFetchConcurrent = 10
 	defaultHTTPTimeoutSec  = 5
+	defaultHostConcurrency = 2
+	defaultHostDelayMillis = 500
+	defaultMediaMaxFileMB  = 512
+	defaultMediaCacheMB    = 4096
 )
 
+const bytesPerMB = 1 << 20
+
 const (
 	defaultUserAgent  = "feed/0.1"
 	configFolderName  = "feed"
@@ -32,6 +38,23 @@ type Config struct {
 	RetentionDays    int
 	HTTPTimeout      time.Duration
 	UserAgent        string
+	HostConcurrency  int
+	HostDelay        time.Duration
+	// MediaMaxFileSize and MediaCacheSize bound downloaded media, in bytes:
+	// larger files are skipped, and the least recently used files are evicted
+	// once the cache grows past its size.
+	MediaMaxFileSize int64
+	MediaCacheSize   int64
+	// FeverAPIKey enables the Fever API in `feed serve`. Clients send
+	// md5("username:password") as the key; it is compared case-insensitively.
+	FeverAPIKey string
+	// GReaderUsername and GReaderPassword enable the Google Reader API in
+	// `feed serve`; clients sign in with them through ClientLogin.
+	GReaderUsername string
+	GReaderPassword string
+	// APIToken, when set, is required as a bearer token on the /v1 JSON API
+	// of `feed serve`. Serving /v1 beyond loopback requires one.
+	APIToken string
 }
 
 func LoadConfig() (Config, error) {
@@ -48,6 +71,10 @@ func LoadConfig() (Config, error) {
 		RetentionDays:    0,
 		HTTPTimeout:      defaultHTTPTimeoutSec * time.Second,
 		UserAgent:        defaultUserAgent,
+		HostConcurrency:  defaultHostConcurrency,
+		HostDelay:        defaultHostDelayMillis * time.Millisecond,
+		MediaMaxFileSize: defaultMediaMaxFileMB * bytesPerMB,
+		MediaCacheSize:   defaultMediaCacheMB * bytesPerMB,
 	}
 
 	configPath, hasConfig, err := findConfigPath(home)
@@ -73,6 +100,18 @@ func LoadConfig() (Config, error) {
 	if cfg.HTTPTimeout <= 0 {
 		cfg.HTTPTimeout = defaultHTTPTimeoutSec * time.Second
 	}
+	if cfg.HostConcurrency < 1 {
+		cfg.HostConcurrency = defaultHostConcurrency
+	}
+	if cfg.HostDelay < 0 {
+		cfg.HostDelay = 0
+	}
+	if cfg.MediaMaxFileSize <= 0 {
+		cfg.MediaMaxFileSize = defaultMediaMaxFileMB * bytesPerMB
+	}
+	if cfg.MediaCacheSize <= 0 {
+		cfg.MediaCacheSize = defaultMediaCacheMB * bytesPerMB
+	}
 	return cfg, nil
 }
 
@@ -81,6 +120,14 @@ type fileConfig struct {
 	StaleMinutes     *int    `toml:"stale_minutes"`
 	FetchConcurrency *int    `toml:"fetch_concurrency"`
 	RetentionDays    *int    `toml:"retention_days"`
+	HostConcurrency  *int    `toml:"host_concurrency"`
+	HostDelayMillis  *int    `toml:"host_delay_ms"`
+	MediaMaxFileMB   *int    `toml:"media_max_file_mb"`
+	MediaCacheMB     *int    `toml:"media_cache_mb"`
+	FeverAPIKey      *string `toml:"fever_api_key"`
+	GReaderUsername  *string `toml:"greader_username"`
+	GReaderPassword  *string `toml:"greader_password"`
+	APIToken         *string `toml:"api_token"`
 }
 
 func findConfigPath(home string) (string, bool, error) {
@@ -139,6 +186,30 @@ func validateFileConfig(path string, cfg fileConfig) error {
 	if cfg.RetentionDays != nil && *cfg.RetentionDays < 0 {
 		return fmt.Errorf("invalid config file %q: retention_days must be >= 0", path)
 	}
+	if cfg.HostConcurrency != nil && *cfg.HostConcurrency < 1 {
+		return fmt.Errorf("invalid config file %q: host_concurrency must be >= 1", path)
+	}
+	if cfg.HostDelayMillis != nil && *cfg.HostDelayMillis < 0 {
+		return fmt.Errorf("invalid config file %q: host_delay_ms must be >= 0", path)
+	}
+	if cfg.MediaMaxFileMB != nil && *cfg.MediaMaxFileMB < 1 {
+		return fmt.Errorf("invalid config file %q: media_max_file_mb must be >= 1", path)
+	}
+	if cfg.MediaCacheMB != nil && *cfg.MediaCacheMB < 1 {
+		return fmt.Errorf("invalid config file %q: media_cache_mb must be >= 1", path)
+	}
+	if cfg.FeverAPIKey != nil && strings.TrimSpace(*cfg.FeverAPIKey) == "" {
+		return fmt.Errorf("invalid config file %q: fever_api_key must be non-empty when provided", path)
+	}
+	if (cfg.GReaderUsername == nil) != (cfg.GReaderPassword == nil) {
+		return fmt.Errorf("invalid config file %q: greader_username and greader_password must be set together", path)
+	}
+	if cfg.GReaderUsername != nil && (strings.TrimSpace(*cfg.GReaderUsername) == "" || *cfg.GReaderPassword == "") {
+		return fmt.Errorf("invalid config file %q: greader_username and greader_password must be non-empty when provided", path)
+	}
+	if cfg.APIToken != nil && strings.TrimSpace(*cfg.APIToken) == "" {
+		return fmt.Errorf("invalid config file %q: api_token must be non-empty when provided", path)
+	}
 	return nil
 }
 
@@ -155,6 +226,30 @@ func applyFileConfig(cfg *Config, fileCfg fileConfig) {
 	if fileCfg.RetentionDays != nil {
 		cfg.RetentionDays = *fileCfg.RetentionDays
 	}
+	if fileCfg.HostConcurrency != nil {
+		cfg.HostConcurrency = *fileCfg.HostConcurrency
+	}
+	if fileCfg.HostDelayMillis != nil {
+		cfg.HostDelay = time.Duration(*fileCfg.HostDelayMillis) * time.Millisecond
+	}
+	if fileCfg.MediaMaxFileMB != nil {
+		cfg.MediaMaxFileSize = int64(*fileCfg.MediaMaxFileMB) * bytesPerMB
+	}
+	if fileCfg.MediaCacheMB != nil {
+		cfg.MediaCacheSize = int64(*fileCfg.MediaCacheMB) * bytesPerMB
+	}
+	if fileCfg.FeverAPIKey != nil {
+		cfg.FeverAPIKey = strings.TrimSpace(*fileCfg.FeverAPIKey)
+	}
+	if fileCfg.GReaderUsername != nil {
+		cfg.GReaderUsername = strings.TrimSpace(*fileCfg.GReaderUsername)
+	}
+	if fileCfg.GReaderPassword != nil {
+		cfg.GReaderPassword = *fileCfg.GReaderPassword
+	}
+	if fileCfg.APIToken != nil {
+		cfg.APIToken = strings.TrimSpace(*fileCfg.APIToken)
+	}
 }
 
 func applyEnvOverrides(cfg *Config) {
@@ -184,4 +279,36 @@ func applyEnvOverrides(cfg *Config) {
 	if v, ok := os.LookupEnv("FEED_USER_AGENT"); ok && v != "" {
 		cfg.UserAgent = v
 	}
+	if v, ok := os.LookupEnv("FEED_HOST_CONCURRENCY"); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			cfg.HostConcurrency = n
+		}
+	}
+	if v, ok := os.LookupEnv("FEED_HOST_DELAY_MS"); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.HostDelay = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v, ok := os.LookupEnv("FEED_MEDIA_MAX_FILE_MB"); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			cfg.MediaMaxFileSize = int64(n) * bytesPerMB
+		}
+	}
+	if v, ok := os.LookupEnv("FEED_MEDIA_CACHE_MB"); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			cfg.MediaCacheSize = int64(n) * bytesPerMB
+		}
+	}
+	if v, ok := os.LookupEnv("FEED_FEVER_API_KEY"); ok && strings.TrimSpace(v) != "" {
+		cfg.FeverAPIKey = strings.TrimSpace(v)
+	}
+	if v, ok := os.LookupEnv("FEED_GREADER_USERNAME"); ok && strings.TrimSpace(v) != "" {
+		cfg.GReaderUsername = strings.TrimSpace(v)
+	}
+	if v, ok := os.LookupEnv("FEED_GREADER_PASSWORD"); ok && v != "" {
+		cfg.GReaderPassword = v
+	}
+	if v, ok := os.LookupEnv("FEED_API_TOKEN"); ok && strings.TrimSpace(v) != "" {
+		cfg.APIToken = strings.TrimSpace(v)
+	}
 }
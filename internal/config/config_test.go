@@ -17,6 +17,14 @@ var configEnvKeys = []string{
 	"FEED_RETENTION_DAYS",
 	"FEED_HTTP_TIMEOUT_SECONDS",
 	"FEED_USER_AGENT",
+	"FEED_HOST_CONCURRENCY",
+	"FEED_HOST_DELAY_MS",
+	"FEED_MEDIA_MAX_FILE_MB",
+	"FEED_MEDIA_CACHE_MB",
+	"FEED_FEVER_API_KEY",
+	"FEED_GREADER_USERNAME",
+	"FEED_GREADER_PASSWORD",
+	"FEED_API_TOKEN",
 }
 
 func setEnvForTest(t *testing.T, key, value string) {
@@ -97,6 +105,18 @@ func TestLoadConfig_NoConfigFileUsesDefaults(t *testing.T) {
 	if cfg.UserAgent != defaultUserAgent {
 		t.Fatalf("UserAgent = %q, want %q", cfg.UserAgent, defaultUserAgent)
 	}
+	if cfg.HostConcurrency != defaultHostConcurrency {
+		t.Fatalf("HostConcurrency = %d, want %d", cfg.HostConcurrency, defaultHostConcurrency)
+	}
+	if cfg.HostDelay != defaultHostDelayMillis*time.Millisecond {
+		t.Fatalf("HostDelay = %s, want %s", cfg.HostDelay, defaultHostDelayMillis*time.Millisecond)
+	}
+	if cfg.MediaMaxFileSize != defaultMediaMaxFileMB*bytesPerMB {
+		t.Fatalf("MediaMaxFileSize = %d, want %d", cfg.MediaMaxFileSize, defaultMediaMaxFileMB*bytesPerMB)
+	}
+	if cfg.MediaCacheSize != defaultMediaCacheMB*bytesPerMB {
+		t.Fatalf("MediaCacheSize = %d, want %d", cfg.MediaCacheSize, defaultMediaCacheMB*bytesPerMB)
+	}
 }
 
 func TestLoadConfig_ConfigFileValuesApplied(t *testing.T) {
@@ -110,6 +130,14 @@ db_path = "`+wantDB+`"
 stale_minutes = 45
 fetch_concurrency = 4
 retention_days = 7
+host_concurrency = 1
+host_delay_ms = 2000
+media_max_file_mb = 64
+media_cache_mb = 1024
+fever_api_key = "0123456789abcdef0123456789abcdef"
+greader_username = "me"
+greader_password = "s3cret"
+api_token = "tok"
 `)
 
 	cfg, err := LoadConfig()
@@ -129,6 +157,27 @@ retention_days = 7
 	if cfg.RetentionDays != 7 {
 		t.Fatalf("RetentionDays = %d, want 7", cfg.RetentionDays)
 	}
+	if cfg.HostConcurrency != 1 {
+		t.Fatalf("HostConcurrency = %d, want 1", cfg.HostConcurrency)
+	}
+	if cfg.HostDelay != 2*time.Second {
+		t.Fatalf("HostDelay = %s, want 2s", cfg.HostDelay)
+	}
+	if cfg.MediaMaxFileSize != 64<<20 {
+		t.Fatalf("MediaMaxFileSize = %d, want 64 MiB", cfg.MediaMaxFileSize)
+	}
+	if cfg.MediaCacheSize != 1024<<20 {
+		t.Fatalf("MediaCacheSize = %d, want 1 GiB", cfg.MediaCacheSize)
+	}
+	if cfg.FeverAPIKey != "0123456789abcdef0123456789abcdef" {
+		t.Fatalf("FeverAPIKey = %q, want the configured key", cfg.FeverAPIKey)
+	}
+	if cfg.GReaderUsername != "me" || cfg.GReaderPassword != "s3cret" {
+		t.Fatalf("GReader credentials = %q/%q, want me/s3cret", cfg.GReaderUsername, cfg.GReaderPassword)
+	}
+	if cfg.APIToken != "tok" {
+		t.Fatalf("APIToken = %q, want tok", cfg.APIToken)
+	}
 	if cfg.HTTPTimeout != defaultHTTPTimeoutSec*time.Second {
 		t.Fatalf("HTTPTimeout = %s, want %s", cfg.HTTPTimeout, defaultHTTPTimeoutSec*time.Second)
 	}
@@ -183,6 +232,7 @@ retention_days = 5
 	setEnvForTest(t, "FEED_RETENTION_DAYS", "11")
 	setEnvForTest(t, "FEED_HTTP_TIMEOUT_SECONDS", "9")
 	setEnvForTest(t, "FEED_USER_AGENT", "feed-test/2.0")
+	setEnvForTest(t, "FEED_MEDIA_CACHE_MB", "256")
 
 	cfg, err := LoadConfig()
 	if err != nil {
@@ -207,6 +257,9 @@ retention_days = 5
 	if cfg.UserAgent != "feed-test/2.0" {
 		t.Fatalf("UserAgent = %q, want %q", cfg.UserAgent, "feed-test/2.0")
 	}
+	if cfg.MediaCacheSize != 256<<20 {
+		t.Fatalf("MediaCacheSize = %d, want 256 MiB", cfg.MediaCacheSize)
+	}
 }
 
 func TestLoadConfig_InvalidOrEmptyEnvDoesNotOverrideConfigFile(t *testing.T) {
@@ -275,6 +328,36 @@ func TestLoadConfig_InvalidConfigReturnsError(t *testing.T) {
 			body:        "retention_days = -1\n",
 			wantSnippet: "retention_days must be >= 0",
 		},
+		{
+			name:        "host_concurrency too small",
+			body:        "host_concurrency = 0\n",
+			wantSnippet: "host_concurrency must be >= 1",
+		},
+		{
+			name:        "host_delay_ms negative",
+			body:        "host_delay_ms = -5\n",
+			wantSnippet: "host_delay_ms must be >= 0",
+		},
+		{
+			name:        "media_cache_mb too small",
+			body:        "media_cache_mb = 0\n",
+			wantSnippet: "media_cache_mb must be >= 1",
+		},
+		{
+			name:        "fever_api_key empty",
+			body:        "fever_api_key = \"\"\n",
+			wantSnippet: "fever_api_key must be non-empty",
+		},
+		{
+			name:        "greader_password missing",
+			body:        "greader_username = \"me\"\n",
+			wantSnippet: "greader_username and greader_password must be set together",
+		},
+		{
+			name:        "api_token empty",
+			body:        "api_token = \" \"\n",
+			wantSnippet: "api_token must be non-empty",
+		},
 		{
 			name:        "db_path empty",
 			body:        "db_path = \"   \"\n",
@@ -12,4 +12,10 @@ type Feed = model.Feed
 type FetchResult = model.FetchResult
 type FetchReport = model.FetchReport
 type EntryListOptions = model.EntryListOptions
+type FetchOptions = model.FetchOptions
 type UpsertEntryInput = model.UpsertEntryInput
+type Enclosure = model.Enclosure
+type Entry = model.Entry
+type MediaFile = model.MediaFile
+type MediaDownloadResult = model.MediaDownloadResult
+type AddFeedResponse = model.AddFeedResponse
@@ -0,0 +1,111 @@
+package fetch
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// itemEnclosures collects an item's media attachments from RSS enclosures
+// (and Atom enclosure links, which gofeed maps to the same field) and Media
+// RSS content elements, resolving relative URLs against base. An iTunes
+// duration fills in enclosures that carry no duration of their own.
+func itemEnclosures(item *gofeed.Item, base *url.URL) []Enclosure {
+	var out []Enclosure
+	seen := make(map[string]int)
+	add := func(enc Enclosure) {
+		enc.URL = resolveReference(base, enc.URL)
+		if enc.URL == "" || !isSafeURL(enc.URL, "a", "href") {
+			return
+		}
+		if i, ok := seen[enc.URL]; ok {
+			// The same file listed twice: keep the first, filling gaps.
+			prev := &out[i]
+			if prev.MimeType == "" {
+				prev.MimeType = enc.MimeType
+			}
+			if prev.Length == 0 {
+				prev.Length = enc.Length
+			}
+			if prev.DurationSeconds == 0 {
+				prev.DurationSeconds = enc.DurationSeconds
+			}
+			return
+		}
+		seen[enc.URL] = len(out)
+		out = append(out, enc)
+	}
+
+	for _, enc := range item.Enclosures {
+		if enc == nil {
+			continue
+		}
+		add(Enclosure{
+			URL:      enc.URL,
+			MimeType: strings.ToLower(strings.TrimSpace(enc.Type)),
+			Length:   parsePositiveInt(enc.Length),
+		})
+	}
+	for _, content := range mediaContents(item.Extensions) {
+		add(Enclosure{
+			URL:             content.Attrs["url"],
+			MimeType:        strings.ToLower(strings.TrimSpace(content.Attrs["type"])),
+			Length:          parsePositiveInt(content.Attrs["fileSize"]),
+			DurationSeconds: int(parsePositiveInt(content.Attrs["duration"])),
+		})
+	}
+
+	if item.ITunesExt != nil {
+		if d := parseITunesDuration(item.ITunesExt.Duration); d > 0 {
+			for i := range out {
+				if out[i].DurationSeconds == 0 {
+					out[i].DurationSeconds = d
+				}
+			}
+		}
+	}
+	return out
+}
+
+// mediaContents returns media:content elements, both directly on the item
+// and inside media:group as YouTube feeds use them.
+func mediaContents(extensions ext.Extensions) []ext.Extension {
+	media := extensions["media"]
+	if media == nil {
+		return nil
+	}
+	contents := append([]ext.Extension(nil), media["content"]...)
+	for _, group := range media["group"] {
+		contents = append(contents, group.Children["content"]...)
+	}
+	return contents
+}
+
+// parseITunesDuration reads an itunes:duration value, given either as
+// seconds or as [[HH:]MM:]SS.
+func parseITunesDuration(raw string) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	total := 0
+	for _, part := range strings.Split(raw, ":") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + int(n)
+	}
+	return total
+}
+
+func parsePositiveInt(raw string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
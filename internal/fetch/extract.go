@@ -0,0 +1,246 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Readability-style heuristics: class and id names that suggest boilerplate
+// or article content.
+var (
+	unlikelyCandidateRegexp = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|menu|newsletter|pager|pagination|popup|related|remark|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe`)
+	maybeCandidateRegexp    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveWeightRegexp    = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeWeightRegexp    = regexp.MustCompile(`(?i)hidden|combx|comment|com-|contact|foot|footnote|masthead|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|social|subscribe`)
+)
+
+var boilerplateTags = map[string]struct{}{
+	"aside":  {},
+	"button": {},
+	"footer": {},
+	"form":   {},
+	"header": {},
+	"nav":    {},
+	"script": {},
+	"style":  {},
+	"svg":    {},
+}
+
+const minParagraphChars = 25
+
+// ExtractArticle parses an HTML page and returns the HTML of its main article
+// content, chosen by scoring paragraph-bearing elements the way Readability
+// does. The result is not sanitized.
+func ExtractArticle(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	body := findBodyNode(doc)
+	if body == nil {
+		return "", fmt.Errorf("page has no body")
+	}
+	pruneBoilerplate(body)
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode || n.Data == "html" {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	walkElements(body, func(n *html.Node) {
+		switch n.Data {
+		case "p", "pre", "td":
+		default:
+			return
+		}
+		text := innerText(n)
+		if len(text) < minParagraphChars {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		scores[n] = score
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		if article := findFirstElement(body, "article"); article != nil {
+			best = article
+		} else {
+			best = body
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<div>")
+	if best.Parent == nil || best == body {
+		renderChildren(&b, best)
+	} else {
+		// Include siblings that look like part of the same article, e.g.
+		// paragraphs split across several wrapper divs.
+		threshold := max(10, bestScore*0.2)
+		for sib := best.Parent.FirstChild; sib != nil; sib = sib.NextSibling {
+			if sib == best || isArticleSibling(sib, scores, threshold) {
+				_ = html.Render(&b, sib)
+			}
+		}
+	}
+	b.WriteString("</div>")
+	return b.String(), nil
+}
+
+func isArticleSibling(n *html.Node, scores map[*html.Node]float64, threshold float64) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if score, ok := scores[n]; ok && score >= threshold {
+		return true
+	}
+	if n.Data != "p" {
+		return false
+	}
+	text := innerText(n)
+	density := linkDensity(n)
+	return (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.HasSuffix(text, "."))
+}
+
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.Data {
+	case "article":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "form", "ol", "ul", "dl", "dd", "dt", "li", "address":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, v := range []string{attrValue(n, "class"), attrValue(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if negativeWeightRegexp.MatchString(v) {
+			weight -= 25
+		}
+		if positiveWeightRegexp.MatchString(v) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// pruneBoilerplate removes navigation, scripts and elements whose class or id
+// marks them as unlikely to be part of the article.
+func pruneBoilerplate(root *html.Node) {
+	var remove []*html.Node
+	walkElements(root, func(n *html.Node) {
+		if _, ok := boilerplateTags[n.Data]; ok {
+			remove = append(remove, n)
+			return
+		}
+		if n.Data == "body" || n.Data == "article" {
+			return
+		}
+		names := attrValue(n, "class") + " " + attrValue(n, "id")
+		if unlikelyCandidateRegexp.MatchString(names) && !maybeCandidateRegexp.MatchString(names) {
+			remove = append(remove, n)
+		}
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
+
+func findFirstElement(n *html.Node, tag string) *html.Node {
+	var found *html.Node
+	walkElements(n, func(c *html.Node) {
+		if found == nil && c.Data == tag {
+			found = c
+		}
+	})
+	return found
+}
+
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(wsRegexp.ReplaceAllString(b.String(), " "))
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(innerText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walkElements(n, func(c *html.Node) {
+		if c.Data == "a" {
+			linked += len(innerText(c))
+		}
+	})
+	return float64(linked) / float64(total)
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func renderChildren(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		_ = html.Render(b, c)
+	}
+}
@@ -0,0 +1,95 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const articlePage = `<!doctype html>
+<html><head><title>Post</title><script>var x = 1;</script></head>
+<body>
+  <nav class="menu"><a href="/">Home</a> <a href="/about">About</a> <a href="/archive">Archive</a></nav>
+  <div id="sidebar"><p>Subscribe to our newsletter, follow us, and read more posts from the archive.</p></div>
+  <div class="post-content">
+    <h1>Why ownership matters</h1>
+    <p>Ownership is the core idea of the language, and it shapes how you structure programs, share data, and reason about lifetimes.</p>
+    <p>Every value has a single owner, and when the owner goes out of scope, the value is dropped, which frees its memory deterministically.</p>
+    <p>Borrowing lets you reference a value without taking ownership, with the compiler checking that references never outlive their data.</p>
+  </div>
+  <div class="comments"><p>Great post, thanks for writing it up, I learned a lot from this one!</p></div>
+  <footer><p>Copyright 2024, all rights reserved, do not reproduce without permission.</p></footer>
+</body></html>`
+
+func TestExtractArticle(t *testing.T) {
+	got, err := ExtractArticle(strings.NewReader(articlePage))
+	if err != nil {
+		t.Fatalf("ExtractArticle: %v", err)
+	}
+	for _, want := range []string{"Every value has a single owner", "Borrowing lets you reference"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected article text %q in %q", want, got)
+		}
+	}
+	for _, unwanted := range []string{"newsletter", "Great post", "Copyright", "Archive", "var x"} {
+		if strings.Contains(got, unwanted) {
+			t.Fatalf("expected boilerplate %q to be dropped, got %q", unwanted, got)
+		}
+	}
+}
+
+func TestExtractArticleFallsBackToBody(t *testing.T) {
+	got, err := ExtractArticle(strings.NewReader(`<html><body><span>short</span></body></html>`))
+	if err != nil {
+		t.Fatalf("ExtractArticle: %v", err)
+	}
+	if !strings.Contains(got, "short") {
+		t.Fatalf("expected body fallback, got %q", got)
+	}
+}
+
+func TestFetchArticleRejectsNonHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/episode.mp3":
+			w.Header().Set("Content-Type", "audio/mpeg")
+		case "/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		}
+		_, _ = w.Write([]byte(articlePage))
+	}))
+	defer srv.Close()
+
+	fetcher := newTestFetcher(newTestStore(t))
+	for _, path := range []string{"/episode.mp3", "/paper.pdf"} {
+		if _, md, err := fetcher.FetchArticle(context.Background(), srv.URL+path); err == nil || !strings.Contains(err.Error(), "not an HTML page") {
+			t.Fatalf("%s: expected a non-HTML error, got md=%q err=%v", path, md, err)
+		}
+	}
+}
+
+func TestFetchArticleDecodesCharset(t *testing.T) {
+	page := strings.Replace(articlePage, "Ownership is the core idea", "Ownership – café – is the core idea", 1)
+	latin1, err := charmap.Windows1252.NewEncoder().String(page)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+		_, _ = w.Write([]byte(latin1))
+	}))
+	defer srv.Close()
+
+	fetcher := newTestFetcher(newTestStore(t))
+	_, md, err := fetcher.FetchArticle(context.Background(), srv.URL+"/post")
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+	if !strings.Contains(md, "Ownership – café – is the core idea") {
+		t.Fatalf("expected decoded text, got %q", md)
+	}
+}
@@ -3,10 +3,14 @@ package fetch
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"sync"
@@ -14,13 +18,18 @@ import (
 	"time"
 
 	"github.com/mmcdole/gofeed"
+	"github.com/odysseus0/feed/internal/store"
 )
 
 type Fetcher struct {
-	store    *Store
-	renderer *Renderer
-	cfg      Config
-	client   *http.Client
+	store       *Store
+	renderer    *Renderer
+	cfg         Config
+	client      *http.Client
+	mediaClient *http.Client
+	hosts       *hostLimiter
+	// mediaMu serializes changes to the set of files in the media cache.
+	mediaMu sync.Mutex
 }
 
 type fetchProgressFn func(done, total int, result FetchResult)
@@ -43,9 +52,15 @@ func NewFetcher(store *Store, renderer *Renderer, cfg Config) *Fetcher {
 		renderer: renderer,
 		cfg:      cfg,
 		client: &http.Client{
-			Timeout:   cfg.HTTPTimeout,
-			Transport: transport,
+			Timeout:       cfg.HTTPTimeout,
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
 		},
+		mediaClient: &http.Client{
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
+		},
+		hosts: newHostLimiter(cfg.HostConcurrency, cfg.HostDelay),
 	}
 }
 
@@ -61,11 +76,58 @@ func (f *Fetcher) DiscoverFeedURL(ctx context.Context, rawURL string) (string, e
 	return DiscoverFeedURL(ctx, f.client, gofeed.NewParser(), rawURL, f.cfg.UserAgent)
 }
 
+// AddFeed subscribes to the feed at rawURL, discovering it from a web page
+// if needed, optionally files it in folder, and fetches it once. Adding a
+// feed that already exists fetches it again and reports Inserted false.
+func (f *Fetcher) AddFeed(ctx context.Context, rawURL, folder string) (AddFeedResponse, error) {
+	if strings.TrimSpace(rawURL) == "" {
+		return AddFeedResponse{}, fmt.Errorf("%w: url is required", store.ErrInvalidInput)
+	}
+	discovered, err := f.DiscoverFeedURL(ctx, rawURL)
+	if err != nil {
+		return AddFeedResponse{}, fmt.Errorf("discover feed url: %w", err)
+	}
+	feed, inserted, err := f.store.CreateFeed(ctx, discovered)
+	if err != nil {
+		return AddFeedResponse{}, fmt.Errorf("create feed: %w", err)
+	}
+	if strings.TrimSpace(folder) != "" {
+		if err := f.store.SetFeedFolder(ctx, feed.ID, folder); err != nil {
+			return AddFeedResponse{}, fmt.Errorf("set feed folder: %w", err)
+		}
+	}
+	report, err := f.Fetch(ctx, &feed.ID)
+	if err != nil {
+		return AddFeedResponse{}, fmt.Errorf("initial fetch: %w", err)
+	}
+	stored, err := f.store.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		return AddFeedResponse{}, fmt.Errorf("get feed %d: %w", feed.ID, err)
+	}
+	return AddFeedResponse{
+		Feed:          stored,
+		Inserted:      inserted,
+		DiscoveredURL: discovered,
+		FetchReport:   report,
+	}, nil
+}
+
 func (f *Fetcher) FetchWithProgress(ctx context.Context, feedID *int64, onResult fetchProgressFn) (FetchReport, error) {
-	feeds, err := f.store.ListFeedsForFetch(ctx, feedID)
+	return f.FetchWithOptions(ctx, FetchOptions{FeedID: feedID}, onResult)
+}
+
+func (f *Fetcher) FetchWithOptions(ctx context.Context, opts FetchOptions, onResult fetchProgressFn) (FetchReport, error) {
+	feeds, err := f.store.ListFeedsForFetch(ctx, opts)
 	if err != nil {
 		return FetchReport{}, err
 	}
+	if opts.FeedID == nil {
+		now := time.Now()
+		feeds = withoutRetryAfter(feeds, now)
+		if !opts.Force {
+			feeds = dueFeeds(feeds, now)
+		}
+	}
 
 	report := FetchReport{StartedAt: time.Now()}
 	if len(feeds) == 0 {
@@ -74,8 +136,16 @@ func (f *Fetcher) FetchWithProgress(ctx context.Context, feedID *int64, onResult
 	}
 
 	results := f.fetchAll(ctx, feeds, onResult)
+	if opts.DownloadMedia {
+		f.autoDownloadMedia(ctx, feeds, results)
+	}
 	sort.Slice(results, func(i, j int) bool { return results[i].FeedID < results[j].FeedID })
 	report.Results = results
+	for _, result := range results {
+		if result.Warning != "" {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("feed %d: %s", result.FeedID, result.Warning))
+		}
+	}
 
 	if f.cfg.RetentionDays > 0 {
 		pruned, pruneErr := f.store.PruneReadEntriesOlderThan(ctx, f.cfg.RetentionDays)
@@ -120,7 +190,7 @@ func (f *Fetcher) fetchAll(ctx context.Context, feeds []Feed, onResult fetchProg
 	}
 
 	go func() {
-		for _, feed := range feeds {
+		for _, feed := range interleaveByHost(feeds) {
 			jobs <- feed
 		}
 		close(jobs)
@@ -139,52 +209,91 @@ func (f *Fetcher) fetchAll(ctx context.Context, feeds []Feed, onResult fetchProg
 }
 
 func (f *Fetcher) fetchSingle(ctx context.Context, feed Feed) FetchResult {
+	result := f.fetchFeed(ctx, feed)
+	// Articles are downloaded after fetchFeed has released its host slot;
+	// they often live on the same host as the feed.
+	if feed.FullContent && result.Error == "" {
+		if warning := f.fillFullContent(ctx, feed); warning != "" {
+			result.Warning = joinWarnings(result.Warning, warning)
+		}
+	}
+	return result
+}
+
+func (f *Fetcher) fetchFeed(ctx context.Context, feed Feed) FetchResult {
 	result := FetchResult{
 		FeedID:    feed.ID,
 		FeedTitle: fallback(feed.Title, feed.URL),
 		FeedURL:   feed.URL,
 	}
+	if feed.RetryAfter != nil && time.Now().Before(*feed.RetryAfter) {
+		result.Error = fmt.Sprintf("server asked to retry after %s", feed.RetryAfter.Local().Format(time.RFC3339))
+		return result
+	}
 
-	req, err := f.newFeedRequest(ctx, feed)
+	release, err := f.hosts.acquire(ctx, feedHost(feed.URL))
 	if err != nil {
-		return f.failFeed(ctx, feed.ID, result, err)
+		return f.failFeed(ctx, feed, result, err)
+	}
+	defer release()
+
+	traceCtx, trace := withRedirectTrace(ctx)
+	req, err := f.newFeedRequest(traceCtx, feed)
+	if err != nil {
+		return f.failFeed(ctx, feed, result, err)
 	}
 
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return f.failFeed(ctx, feed.ID, result, err)
+		return f.failFeed(ctx, feed, result, err)
 	}
 	defer resp.Body.Close()
 
 	etag, lastModified := mergeCacheHeaders(resp, feed)
 	if resp.StatusCode == http.StatusNotModified {
 		result.NotModified = true
+		f.recordPermanentRedirect(ctx, feed, trace, &result)
 		if err := f.store.UpdateFeedFetchSuccess(ctx, feed.ID, "", "", "", etag, lastModified, time.Now()); err != nil {
-			return f.failFeed(ctx, feed.ID, result, err)
+			return f.failFeed(ctx, feed, result, err)
+		}
+		if err := f.scheduleNextFetch(ctx, feed, resp.Header); err != nil {
+			return f.failFeed(ctx, feed, result, err)
 		}
 		return result
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return f.deferFeed(ctx, feed, result, resp)
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return f.failFeed(ctx, feed.ID, result, fmt.Errorf("http %d", resp.StatusCode))
+		return f.failFeed(ctx, feed, result, fmt.Errorf("http %d", resp.StatusCode))
 	}
 
-	parsed, err := parseFeedResponse(resp.Body)
+	docURL := resp.Request.URL
+	parsed, itemBases, err := parseFeedResponse(resp.Body, docURL)
 	if err != nil {
-		return f.failFeed(ctx, feed.ID, result, err)
+		return f.failFeed(ctx, feed, result, err)
 	}
 
-	newCount, updatedCount, err := f.storeFeedItems(ctx, feed.ID, parsed.Items)
+	siteBase := docURL
+	if u := absoluteURL(docURL, parsed.Link); u != nil {
+		siteBase = u
+	}
+	newCount, updatedCount, err := f.storeFeedItems(ctx, feed.ID, siteBase, parsed.Items, itemBases)
 	if err != nil {
-		return f.failFeed(ctx, feed.ID, result, err)
+		return f.failFeed(ctx, feed, result, err)
 	}
 	result.NewEntries = newCount
 	result.Updated = updatedCount
+	f.recordPermanentRedirect(ctx, feed, trace, &result)
 
-	if err := f.store.UpdateFeedFetchSuccess(ctx, feed.ID, strings.TrimSpace(parsed.Title), strings.TrimSpace(parsed.Link), strings.TrimSpace(parsed.Description), etag, lastModified, time.Now()); err != nil {
-		return f.failFeed(ctx, feed.ID, result, err)
+	if err := f.store.UpdateFeedFetchSuccess(ctx, feed.ID, strings.TrimSpace(parsed.Title), resolveReference(docURL, parsed.Link), strings.TrimSpace(parsed.Description), etag, lastModified, time.Now()); err != nil {
+		return f.failFeed(ctx, feed, result, err)
+	}
+	if err := f.scheduleNextFetch(ctx, feed, resp.Header); err != nil {
+		return f.failFeed(ctx, feed, result, err)
 	}
-	if parsed.Title != "" {
+	if parsed.Title != "" && feed.CustomTitle == "" {
 		result.FeedTitle = parsed.Title
 	}
 	return result
@@ -218,71 +327,139 @@ func mergeCacheHeaders(resp *http.Response, feed Feed) (etag, lastModified strin
 	return etag, lastModified
 }
 
-func parseFeedResponse(body io.Reader) (*gofeed.Feed, error) {
+// parseFeedResponse parses a feed document. For RSS it also returns the
+// xml:base in effect for each item, see rssItemBases.
+func parseFeedResponse(body io.Reader, docURL *url.URL) (*gofeed.Feed, []*url.URL, error) {
 	data, err := io.ReadAll(io.LimitReader(body, 16<<20))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return gofeed.NewParser().Parse(bytes.NewReader(data))
+	parsed, err := gofeed.NewParser().Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	var itemBases []*url.URL
+	if parsed.FeedType == "rss" {
+		itemBases = rssItemBases(data, docURL)
+	}
+	return parsed, itemBases, nil
 }
 
-func (f *Fetcher) storeFeedItems(ctx context.Context, feedID int64, items []*gofeed.Item) (newCount int, updatedCount int, err error) {
-	for _, item := range items {
+// storeFeedItems upserts the feed's items. Relative URLs in an item's link
+// and content are resolved against its xml:base or siteBase, then against
+// the item link itself, so stored content only carries absolute links.
+func (f *Fetcher) storeFeedItems(ctx context.Context, feedID int64, siteBase *url.URL, items []*gofeed.Item, itemBases []*url.URL) (newCount int, updatedCount int, err error) {
+	entries := make([]UpsertEntryInput, 0, len(items))
+	for i, item := range items {
 		guid := strings.TrimSpace(item.GUID)
 		if guid == "" {
 			guid = dedupGUID(item.Link, item.Title, item.PublishedParsed)
 		}
 
+		base := siteBase
+		if i < len(itemBases) && itemBases[i] != nil {
+			base = itemBases[i]
+		}
+		sourceHash := itemSourceHash(item, base)
+		link := resolveReference(base, item.Link)
+		if u := absoluteURL(base, item.Link); u != nil {
+			base = u
+		}
+
 		contentHTML := strings.TrimSpace(item.Content)
 		if contentHTML == "" {
 			contentHTML = strings.TrimSpace(item.Description)
 		}
-		contentHTML = SanitizeHTML(contentHTML)
+		contentHTML = SanitizeHTMLWithBase(contentHTML, base)
 
 		author := ""
 		if item.Author != nil {
 			author = strings.TrimSpace(item.Author.Name)
 		}
 
-		_, inserted, err := f.store.UpsertEntry(ctx, UpsertEntryInput{
+		entries = append(entries, UpsertEntryInput{
 			FeedID:       feedID,
 			GUID:         guid,
-			URL:          strings.TrimSpace(item.Link),
+			URL:          link,
 			ExternalURL:  "",
 			Title:        strings.TrimSpace(item.Title),
-			Summary:      summarize(item.Description, f.renderer),
+			Summary:      summarize(item.Description, base, f.renderer),
 			ContentHTML:  contentHTML,
 			ContentMD:    f.renderer.HTMLToMarkdown(contentHTML),
 			Author:       author,
 			PublishedAt:  item.PublishedParsed,
 			DateModified: item.UpdatedParsed,
+			Enclosures:   itemEnclosures(item, base),
+			SourceHash:   sourceHash,
 		})
-		if err != nil {
-			return newCount, updatedCount, err
-		}
-		if inserted {
-			newCount++
-		} else {
-			updatedCount++
+	}
+	return f.store.UpsertEntries(ctx, entries)
+}
+
+// itemSourceHash fingerprints an item as the feed published it, before any
+// sanitizing or rendering, so the store can tell an edited item from one
+// that only renders differently after an upgrade.
+func itemSourceHash(item *gofeed.Item, base *url.URL) string {
+	h := sha256.New()
+	fields := []string{item.GUID, item.Link, item.Title, item.Description, item.Content, item.Published, item.Updated}
+	if base != nil {
+		fields = append(fields, base.String())
+	}
+	if item.Author != nil {
+		fields = append(fields, item.Author.Name, item.Author.Email)
+	}
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	// Enclosures also come from Media RSS and iTunes elements.
+	_ = json.NewEncoder(h).Encode([]any{item.Enclosures, item.Extensions["media"], item.ITunesExt})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scheduleNextFetch sets when the feed is next due after a successful fetch,
+// based on its posting frequency and the response's cache lifetime.
+func (f *Fetcher) scheduleNextFetch(ctx context.Context, feed Feed, header http.Header) error {
+	gap, err := f.store.FeedPostingInterval(ctx, feed.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return f.store.SetFeedNextFetchAt(ctx, feed.ID, now.Add(pollInterval(gap, cacheLifetime(header, now))))
+}
+
+// deferFeed records a 429/503 response, storing the Retry-After time if the
+// server sent one so later fetch runs leave the feed alone until then.
+func (f *Fetcher) deferFeed(ctx context.Context, feed Feed, result FetchResult, resp *http.Response) FetchResult {
+	err := fmt.Errorf("http %d", resp.StatusCode)
+	if until, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if persistErr := f.store.SetFeedRetryAfter(ctx, feed.ID, until); persistErr != nil {
+			return f.failFeed(ctx, feed, result, fmt.Errorf("%v; additionally failed to persist retry-after: %v", err, persistErr))
 		}
+		err = fmt.Errorf("http %d (retry after %s)", resp.StatusCode, until.Local().Format(time.RFC3339))
 	}
-	return newCount, updatedCount, nil
+	return f.failFeed(ctx, feed, result, err)
 }
 
-func (f *Fetcher) failFeed(ctx context.Context, feedID int64, result FetchResult, err error) FetchResult {
+func (f *Fetcher) failFeed(ctx context.Context, feed Feed, result FetchResult, err error) FetchResult {
 	result.Error = err.Error()
-	if persistErr := f.store.SetFeedError(ctx, feedID, result.Error); persistErr != nil {
+	if persistErr := f.store.SetFeedError(ctx, feed.ID, result.Error); persistErr != nil {
 		result.Error = fmt.Sprintf("%s; additionally failed to persist feed error: %v", result.Error, persistErr)
+		return result
+	}
+	next := time.Now().Add(errorBackoff(feed.ErrorCount + 1))
+	if persistErr := f.store.SetFeedNextFetchAt(ctx, feed.ID, next); persistErr != nil {
+		result.Error = fmt.Sprintf("%s; additionally failed to schedule retry: %v", result.Error, persistErr)
 	}
 	return result
 }
 
-func summarize(raw string, renderer *Renderer) string {
+func summarize(raw string, base *url.URL, renderer *Renderer) string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return ""
 	}
-	raw = SanitizeHTML(raw)
+	raw = SanitizeHTMLWithBase(raw, base)
 	md := renderer.HTMLToMarkdown(raw)
 	return compactText(md, 280)
 }
@@ -2,14 +2,18 @@ package fetch
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/model"
 	"github.com/odysseus0/feed/internal/store"
 )
 
@@ -294,3 +298,850 @@ func TestFetcherReportsPruneWarning(t *testing.T) {
 		t.Fatalf("expected prune warning, got %#v", rep.Warnings)
 	}
 }
+
+func TestFetcherHonorsPerFeedInterval(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newTestFetcher(s)
+	ctx := context.Background()
+
+	var reqCount int32
+	const feedXML = `<?xml version="1.0"?><rss version="2.0"><channel><title>T</title><link>https://example.com</link></channel></rss>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feedXML))
+	}))
+	defer srv.Close()
+
+	feed := mustCreateFeed(t, s, srv.URL)
+	interval := 60
+	if _, err := s.UpdateFeed(ctx, feed.ID, store.UpdateFeedInput{FetchIntervalMinutes: &interval}); err != nil {
+		t.Fatalf("set interval: %v", err)
+	}
+
+	if _, err := fetcher.Fetch(ctx, nil); err != nil {
+		t.Fatalf("fetch 1: %v", err)
+	}
+	rep, err := fetcher.Fetch(ctx, nil)
+	if err != nil {
+		t.Fatalf("fetch 2: %v", err)
+	}
+	if len(rep.Results) != 0 {
+		t.Fatalf("expected feed within interval to be skipped, got %+v", rep.Results)
+	}
+	if _, err := fetcher.Fetch(ctx, &feed.ID); err != nil {
+		t.Fatalf("fetch by id: %v", err)
+	}
+	if got := atomic.LoadInt32(&reqCount); got != 2 {
+		t.Fatalf("expected 2 requests (initial + explicit), got %d", got)
+	}
+}
+
+func TestFetcherPersistsPermanentRedirects(t *testing.T) {
+	store := newTestStore(t)
+	fetcher := newTestFetcher(store)
+	ctx := context.Background()
+
+	const feedXML = `<?xml version="1.0"?><rss version="2.0"><channel><title>Moved</title>
+<item><guid>moved-1</guid><title>Moved One</title></item></channel></rss>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/temp":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/taken":
+			http.Redirect(w, r, "/dup", http.StatusPermanentRedirect)
+		default:
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = w.Write([]byte(feedXML))
+		}
+	}))
+	defer srv.Close()
+
+	moved := mustCreateFeed(t, store, srv.URL+"/old")
+	temp := mustCreateFeed(t, store, srv.URL+"/temp")
+	taken := mustCreateFeed(t, store, srv.URL+"/taken")
+	mustCreateFeed(t, store, srv.URL+"/dup")
+
+	rep, err := fetcher.Fetch(ctx, &moved.ID)
+	if err != nil {
+		t.Fatalf("fetch moved: %v", err)
+	}
+	if got := rep.Results[0].MovedTo; got != srv.URL+"/new" {
+		t.Fatalf("expected moved_to %q, got %+v", srv.URL+"/new", rep.Results[0])
+	}
+	if feed, _ := store.GetFeedByID(ctx, moved.ID); feed.URL != srv.URL+"/new" {
+		t.Fatalf("expected stored url to follow 301, got %q", feed.URL)
+	}
+
+	rep, err = fetcher.Fetch(ctx, &temp.ID)
+	if err != nil {
+		t.Fatalf("fetch temp: %v", err)
+	}
+	if rep.Results[0].MovedTo != "" {
+		t.Fatalf("temporary redirect must not move feed: %+v", rep.Results[0])
+	}
+	if feed, _ := store.GetFeedByID(ctx, temp.ID); feed.URL != srv.URL+"/temp" {
+		t.Fatalf("expected url unchanged after 302, got %q", feed.URL)
+	}
+
+	rep, err = fetcher.Fetch(ctx, &taken.ID)
+	if err != nil {
+		t.Fatalf("fetch taken: %v", err)
+	}
+	if rep.Results[0].Error != "" || rep.Results[0].MovedTo != "" || len(rep.Warnings) != 1 {
+		t.Fatalf("expected conflict to be reported as warning, got %+v", rep)
+	}
+	if feed, _ := store.GetFeedByID(ctx, taken.ID); feed.URL != srv.URL+"/taken" {
+		t.Fatalf("expected url unchanged on conflict, got %q", feed.URL)
+	}
+}
+
+func TestFetcherSchedulesNextFetch(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newTestFetcher(s)
+	ctx := context.Background()
+
+	var reqCount int32
+	const feedXML = `<?xml version="1.0"?><rss version="2.0"><channel><title>T</title><link>https://example.com</link></channel></rss>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=7200")
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feedXML))
+	}))
+	defer srv.Close()
+
+	ok := mustCreateFeed(t, s, srv.URL+"/ok")
+	broken := mustCreateFeed(t, s, srv.URL+"/broken")
+
+	before := time.Now()
+	if _, err := fetcher.Fetch(ctx, nil); err != nil {
+		t.Fatalf("fetch 1: %v", err)
+	}
+	okFeed, _ := s.GetFeedByID(ctx, ok.ID)
+	if okFeed.NextFetchAt == nil || okFeed.NextFetchAt.Before(before.Add(2*time.Hour-time.Minute)) {
+		t.Fatalf("expected next fetch to honor max-age, got %v", okFeed.NextFetchAt)
+	}
+	brokenFeed, _ := s.GetFeedByID(ctx, broken.ID)
+	if brokenFeed.NextFetchAt == nil || brokenFeed.NextFetchAt.After(time.Now().Add(baseErrorBackoff)) {
+		t.Fatalf("expected first failure to back off by %s, got %v", baseErrorBackoff, brokenFeed.NextFetchAt)
+	}
+
+	rep, err := fetcher.Fetch(ctx, nil)
+	if err != nil {
+		t.Fatalf("fetch 2: %v", err)
+	}
+	if len(rep.Results) != 0 {
+		t.Fatalf("expected feeds not yet due to be skipped, got %+v", rep.Results)
+	}
+	rep, err = fetcher.FetchWithOptions(ctx, FetchOptions{Force: true}, nil)
+	if err != nil {
+		t.Fatalf("forced fetch: %v", err)
+	}
+	if len(rep.Results) != 2 || atomic.LoadInt32(&reqCount) != 4 {
+		t.Fatalf("expected --force to fetch both feeds, got %+v (requests=%d)", rep.Results, reqCount)
+	}
+}
+
+func TestPollSchedule(t *testing.T) {
+	if got := pollInterval(0, 0); got != defaultPollInterval {
+		t.Fatalf("pollInterval without history = %s", got)
+	}
+	if got := pollInterval(4*time.Hour, 0); got != 2*time.Hour {
+		t.Fatalf("pollInterval(4h) = %s, want 2h", got)
+	}
+	if got := pollInterval(time.Minute, 0); got != minPollInterval {
+		t.Fatalf("pollInterval clamps to min, got %s", got)
+	}
+	if got := pollInterval(30*24*time.Hour, 0); got != maxPollInterval {
+		t.Fatalf("pollInterval clamps to max, got %s", got)
+	}
+	if got := pollInterval(time.Hour, 3*time.Hour); got != 3*time.Hour {
+		t.Fatalf("pollInterval honors cache lifetime, got %s", got)
+	}
+
+	wantBackoff := []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}
+	for i, want := range wantBackoff {
+		if got := errorBackoff(i + 1); got != want {
+			t.Fatalf("errorBackoff(%d) = %s, want %s", i+1, got, want)
+		}
+	}
+	if got := errorBackoff(50); got != maxPollInterval {
+		t.Fatalf("errorBackoff caps at %s, got %s", maxPollInterval, got)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("Expires", now.Add(90*time.Minute).Format(http.TimeFormat))
+	if got := cacheLifetime(h, now); got != 90*time.Minute {
+		t.Fatalf("cacheLifetime from Expires = %s", got)
+	}
+	h.Set("Cache-Control", "max-age=600")
+	if got := cacheLifetime(h, now); got != 10*time.Minute {
+		t.Fatalf("cacheLifetime prefers max-age, got %s", got)
+	}
+	h.Set("Cache-Control", "no-store")
+	if got := cacheLifetime(h, now); got != 0 {
+		t.Fatalf("cacheLifetime no-store = %s", got)
+	}
+}
+
+func TestFetcherHonorsRetryAfter(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newTestFetcher(s)
+	ctx := context.Background()
+
+	var reqCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	feed := mustCreateFeed(t, s, srv.URL)
+	before := time.Now()
+	rep, err := fetcher.Fetch(ctx, nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(rep.Results) != 1 || !strings.Contains(rep.Results[0].Error, "http 429 (retry after") {
+		t.Fatalf("expected 429 with retry-after in error, got %+v", rep.Results)
+	}
+	updated, err := s.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if updated.RetryAfter == nil || updated.RetryAfter.Before(before.Add(59*time.Minute)) {
+		t.Fatalf("expected retry_after about an hour out, got %v", updated.RetryAfter)
+	}
+
+	rep, err = fetcher.FetchWithOptions(ctx, FetchOptions{Force: true}, nil)
+	if err != nil {
+		t.Fatalf("forced fetch: %v", err)
+	}
+	if len(rep.Results) != 0 {
+		t.Fatalf("expected forced fetch to respect Retry-After, got %+v", rep.Results)
+	}
+	rep, err = fetcher.Fetch(ctx, &feed.ID)
+	if err != nil {
+		t.Fatalf("fetch by id: %v", err)
+	}
+	if len(rep.Results) != 1 || !strings.Contains(rep.Results[0].Error, "retry after") {
+		t.Fatalf("expected explicit fetch to report retry-after, got %+v", rep.Results)
+	}
+	if got := atomic.LoadInt32(&reqCount); got != 1 {
+		t.Fatalf("expected a single request while deferred, got %d", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got, ok := parseRetryAfter("120", now); !ok || !got.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("seconds form = %v, %v", got, ok)
+	}
+	date := now.Add(time.Hour).Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(date, now); !ok || !got.Equal(now.Add(time.Hour)) {
+		t.Fatalf("date form = %v, %v", got, ok)
+	}
+	if got, ok := parseRetryAfter("99999999", now); !ok || !got.Equal(now.Add(maxRetryAfter)) {
+		t.Fatalf("expected cap at %s, got %v", maxRetryAfter, got)
+	}
+	for _, raw := range []string{"", "soon", "0", now.Add(-time.Hour).Format(http.TimeFormat)} {
+		if _, ok := parseRetryAfter(raw, now); ok {
+			t.Fatalf("parseRetryAfter(%q) should be ignored", raw)
+		}
+	}
+}
+
+func TestFetcherCapsConcurrentRequestsPerHost(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	var inFlight, maxInFlight int32
+	const feedXML = `<?xml version="1.0"?><rss version="2.0"><channel><title>T</title></channel></rss>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feedXML))
+	}))
+	defer srv.Close()
+
+	for i := 0; i < 6; i++ {
+		mustCreateFeed(t, s, srv.URL+"/"+string(rune('a'+i)))
+	}
+	fetcher := NewFetcher(s, NewRenderer(), config.Config{
+		HTTPTimeout:      5 * time.Second,
+		FetchConcurrency: 8,
+		HostConcurrency:  2,
+		UserAgent:        "feed-test/1.0",
+	})
+	rep, err := fetcher.Fetch(ctx, nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(rep.Results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(rep.Results))
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Fatalf("expected at most 2 concurrent requests to one host, saw %d", got)
+	}
+}
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+	limiter := newHostLimiter(4, 40*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire(ctx, "example.com")
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected requests to the same host to be spaced out, took %s", elapsed)
+	}
+
+	start = time.Now()
+	release, err := limiter.acquire(ctx, "other.example.com")
+	if err != nil {
+		t.Fatalf("acquire other host: %v", err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("expected a distinct host not to wait, took %s", elapsed)
+	}
+}
+
+func TestInterleaveByHost(t *testing.T) {
+	feeds := []Feed{
+		{ID: 1, URL: "https://a.example.com/1"},
+		{ID: 2, URL: "https://a.example.com/2"},
+		{ID: 3, URL: "https://A.example.com/3"},
+		{ID: 4, URL: "https://b.example.com/1"},
+		{ID: 5, URL: "https://c.example.com/1"},
+	}
+	got := interleaveByHost(feeds)
+	want := []int64{1, 4, 5, 2, 3}
+	for i, feed := range got {
+		if feed.ID != want[i] {
+			t.Fatalf("interleaveByHost order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFetcherExtractsFullContentWhenEnabled(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newTestFetcher(s)
+	ctx := context.Background()
+
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>T</title>
+<item><guid>p1</guid><title>Post</title><link>` + srvURL + `/post</link><description>Teaser only.</description></item>
+<item><guid>p2</guid><title>Gone</title><link>` + srvURL + `/gone</link><description>Teaser.</description></item>
+</channel></rss>`))
+		case "/post":
+			_, _ = w.Write([]byte(articlePage))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	feed := mustCreateFeed(t, s, srv.URL+"/feed.xml")
+	enabled := true
+	if _, err := s.UpdateFeed(ctx, feed.ID, store.UpdateFeedInput{FullContent: &enabled}); err != nil {
+		t.Fatalf("enable full content: %v", err)
+	}
+
+	rep, err := fetcher.Fetch(ctx, &feed.ID)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(rep.Results) != 1 || !strings.Contains(rep.Results[0].Warning, "1 of 2 articles") {
+		t.Fatalf("expected one extraction failure to be reported, got %+v", rep.Results)
+	}
+
+	entries, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Limit: 10})
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	var gone int64
+	for _, e := range entries {
+		switch e.GUID {
+		case "p1":
+			if e.FullContentFetchedAt == nil || !strings.Contains(e.FullContentMD, "Every value has a single owner") || e.ContentMD != "Teaser only." {
+				t.Fatalf("expected full article next to teaser, got content=%q full=%q", e.ContentMD, e.FullContentMD)
+			}
+		case "p2":
+			gone = e.ID
+			if e.FullContentFetchedAt != nil || e.FullContentMD != "" {
+				t.Fatalf("expected no full content for missing page, got %q", e.FullContentMD)
+			}
+		}
+	}
+
+	// The failed page is retried on later fetches until it has failed three
+	// times.
+	for attempt := 2; attempt <= 3; attempt++ {
+		missing, err := s.ListEntriesMissingFullContent(ctx, feed.ID, 10)
+		if err != nil {
+			t.Fatalf("list missing full content: %v", err)
+		}
+		if len(missing) != 1 || missing[0].ID != gone {
+			t.Fatalf("attempt %d: expected only the failed entry to be retried, got %+v", attempt, missing)
+		}
+		if err := s.RecordFullContentFailure(ctx, gone, "http 404"); err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+	}
+	if missing, err := s.ListEntriesMissingFullContent(ctx, feed.ID, 10); err != nil || len(missing) != 0 {
+		t.Fatalf("expected no retries after three failures, got %+v, %v", missing, err)
+	}
+}
+
+func TestFetcherResolvesRelativeURLs(t *testing.T) {
+	store := newTestStore(t)
+	fetcher := newTestFetcher(store)
+	ctx := context.Background()
+
+	const feedXML = `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<title>Test Feed</title><link>https://blog.example/</link>
+<item>
+  <title>Absolute link</title>
+  <guid>a</guid>
+  <link>https://blog.example/posts/a/</link>
+  <description><![CDATA[<p><img src="../../img/a.png" alt="A"> <a href="/about">about</a> <a href="#notes">notes</a></p>]]></description>
+</item>
+<item>
+  <title>Relative link</title>
+  <guid>b</guid>
+  <link>/posts/b/</link>
+  <description><![CDATA[<p><a href="next">next</a></p>]]></description>
+</item>
+<item xml:base="https://mirror.example/archive/">
+  <title>XML base</title>
+  <guid>c</guid>
+  <link>post-c.html</link>
+  <description><![CDATA[<p><a href="notes.html">notes</a></p>]]></description>
+</item>
+</channel></rss>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feedXML))
+	}))
+	defer srv.Close()
+
+	feed := mustCreateFeed(t, store, srv.URL)
+	if _, err := fetcher.Fetch(ctx, &feed.ID); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	entries, err := store.ListEntries(ctx, EntryListOptions{Status: "all", Limit: 10})
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	byGUID := make(map[string]model.Entry)
+	for _, e := range entries {
+		full, err := store.GetEntry(ctx, e.ID)
+		if err != nil {
+			t.Fatalf("get entry: %v", err)
+		}
+		byGUID[e.GUID] = full
+	}
+
+	a := byGUID["a"]
+	for _, want := range []string{`src="https://blog.example/img/a.png"`, `href="https://blog.example/about"`, `href="https://blog.example/posts/a/#notes"`} {
+		if !strings.Contains(a.ContentHTML, want) {
+			t.Fatalf("expected %s in content, got %s", want, a.ContentHTML)
+		}
+	}
+	if !strings.Contains(a.ContentMD, "https://blog.example/about") {
+		t.Fatalf("expected absolute link in markdown, got %s", a.ContentMD)
+	}
+
+	b := byGUID["b"]
+	if b.URL != "https://blog.example/posts/b/" {
+		t.Fatalf("expected item link resolved against feed link, got %q", b.URL)
+	}
+	if !strings.Contains(b.ContentHTML, `href="https://blog.example/posts/b/next"`) {
+		t.Fatalf("expected link resolved against item link, got %s", b.ContentHTML)
+	}
+
+	c := byGUID["c"]
+	if c.URL != "https://mirror.example/archive/post-c.html" {
+		t.Fatalf("expected item link resolved against xml:base, got %q", c.URL)
+	}
+	if !strings.Contains(c.ContentHTML, `href="https://mirror.example/archive/notes.html"`) {
+		t.Fatalf("expected content resolved against xml:base item link, got %s", c.ContentHTML)
+	}
+}
+
+func TestFetcherStoresEnclosures(t *testing.T) {
+	store := newTestStore(t)
+	fetcher := newTestFetcher(store)
+	ctx := context.Background()
+
+	const feedXML = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+<title>Podcast</title><link>https://pod.example/</link>
+<item>
+  <title>Episode 1</title>
+  <guid>ep1</guid>
+  <enclosure url="/audio/ep1.mp3" length="4096" type="audio/mpeg"/>
+  <media:content url="https://pod.example/audio/ep1.mp3" fileSize="4096" type="audio/mpeg"/>
+  <itunes:duration>1:02:03</itunes:duration>
+</item>
+<item>
+  <title>Video</title>
+  <guid>v1</guid>
+  <media:group>
+    <media:content url="https://video.example/v/abc" type="application/x-shockwave-flash" duration="95"/>
+    <media:thumbnail url="https://video.example/abc.jpg"/>
+  </media:group>
+</item>
+</channel></rss>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feedXML))
+	}))
+	defer srv.Close()
+
+	feed := mustCreateFeed(t, store, srv.URL)
+	if _, err := fetcher.Fetch(ctx, &feed.ID); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	items, err := store.ListEnclosures(ctx, model.EnclosureListOptions{})
+	if err != nil {
+		t.Fatalf("list enclosures: %v", err)
+	}
+	byURL := make(map[string]model.EntryEnclosure)
+	for _, item := range items {
+		byURL[item.URL] = item
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected duplicate enclosure to be merged, got %+v", items)
+	}
+	ep := byURL["https://pod.example/audio/ep1.mp3"]
+	if ep.MimeType != "audio/mpeg" || ep.Length != 4096 || ep.DurationSeconds != 3723 {
+		t.Fatalf("unexpected podcast enclosure: %+v", ep)
+	}
+	if v := byURL["https://video.example/v/abc"]; v.DurationSeconds != 95 || v.EntryTitle != "Video" {
+		t.Fatalf("unexpected media:group enclosure: %+v", v)
+	}
+}
+
+func TestParseITunesDuration(t *testing.T) {
+	for raw, want := range map[string]int{
+		"":         0,
+		"3600":     3600,
+		"42:10":    2530,
+		"1:02:03":  3723,
+		"01:00:00": 3600,
+		"bogus":    0,
+	} {
+		if got := parseITunesDuration(raw); got != want {
+			t.Fatalf("parseITunesDuration(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func newMediaTestFetcher(t *testing.T, s *store.Store, maxFile, cacheSize int64) *Fetcher {
+	t.Helper()
+	cfg := config.Config{
+		DBPath:           filepath.Join(t.TempDir(), "feed.db"),
+		HTTPTimeout:      5 * time.Second,
+		FetchConcurrency: 4,
+		UserAgent:        "feed-test/1.0",
+		MediaMaxFileSize: maxFile,
+		MediaCacheSize:   cacheSize,
+	}
+	return NewFetcher(s, NewRenderer(), cfg)
+}
+
+func newMediaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".mp3"):
+			w.Header().Set("Content-Type", "audio/mpeg")
+			_, _ = w.Write(make([]byte, 100))
+		case r.URL.Path == "/img/chart":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(make([]byte, 50))
+		case r.URL.Path == "/big.bin":
+			_, _ = w.Write(make([]byte, 5000))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetcherDownloadsEntryMedia(t *testing.T) {
+	s := newTestStore(t)
+	srv := newMediaServer(t)
+	fetcher := newMediaTestFetcher(t, s, 1000, 1<<20)
+	ctx := context.Background()
+
+	feed := mustCreateFeed(t, s, srv.URL+"/feed.xml")
+	entryID, _, err := s.UpsertEntry(ctx, model.UpsertEntryInput{
+		FeedID:      feed.ID,
+		GUID:        "ep1",
+		ContentHTML: `<p><img src="` + srv.URL + `/img/chart" alt="Chart"></p>`,
+		Enclosures: []model.Enclosure{
+			{URL: srv.URL + "/ep1.mp3", MimeType: "audio/mpeg"},
+			{URL: srv.URL + "/big.bin"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("upsert entry: %v", err)
+	}
+
+	result, err := fetcher.DownloadEntryMedia(ctx, entryID)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if result.Downloaded != 2 || result.Bytes != 150 || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "size limit") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	local, err := fetcher.LocalMedia(ctx, entryID)
+	if err != nil {
+		t.Fatalf("local media: %v", err)
+	}
+	mp3 := local[srv.URL+"/ep1.mp3"]
+	if !strings.HasPrefix(mp3, fetcher.MediaDir()) || filepath.Ext(mp3) != ".mp3" {
+		t.Fatalf("unexpected local path %q", mp3)
+	}
+	if info, err := os.Stat(local[srv.URL+"/img/chart"]); err != nil || info.Size() != 50 || filepath.Ext(info.Name()) != ".png" {
+		t.Fatalf("expected image saved with an extension from its MIME type, got %v, %v", info, err)
+	}
+
+	result, err = fetcher.DownloadEntryMedia(ctx, entryID)
+	if err != nil {
+		t.Fatalf("second download: %v", err)
+	}
+	if result.Downloaded != 0 || result.Cached != 2 {
+		t.Fatalf("expected cached files to be reused, got %+v", result)
+	}
+
+	// The oversized file keeps the entry queued for automatic downloads
+	// until it has failed three times.
+	since := time.Now().Add(-time.Hour)
+	if pending, err := s.ListEntriesMissingMedia(ctx, feed.ID, since, 10); err != nil || len(pending) != 1 {
+		t.Fatalf("expected the entry to stay queued after two failures, got %v, %v", pending, err)
+	}
+	if _, err := fetcher.DownloadEntryMedia(ctx, entryID); err != nil {
+		t.Fatalf("third download: %v", err)
+	}
+	if pending, err := s.ListEntriesMissingMedia(ctx, feed.ID, since, 10); err != nil || len(pending) != 0 {
+		t.Fatalf("expected no retries after three failures, got %v, %v", pending, err)
+	}
+}
+
+func TestFetcherEvictsLeastRecentlyUsedMedia(t *testing.T) {
+	s := newTestStore(t)
+	srv := newMediaServer(t)
+	fetcher := newMediaTestFetcher(t, s, 1000, 250)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, srv.URL+"/feed.xml")
+
+	ids := make([]int64, 3)
+	for i := range ids {
+		id, _, err := s.UpsertEntry(ctx, model.UpsertEntryInput{
+			FeedID:     feed.ID,
+			GUID:       fmt.Sprintf("ep%d", i),
+			Enclosures: []model.Enclosure{{URL: fmt.Sprintf("%s/ep%d.mp3", srv.URL, i)}},
+		})
+		if err != nil {
+			t.Fatalf("upsert entry: %v", err)
+		}
+		ids[i] = id
+	}
+	download := func(id int64) {
+		t.Helper()
+		if _, err := fetcher.DownloadEntryMedia(ctx, id); err != nil {
+			t.Fatalf("download entry %d: %v", id, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	download(ids[0])
+	download(ids[1])
+	// Reading the first entry makes the second one the least recently used.
+	if _, err := fetcher.LocalMedia(ctx, ids[0]); err != nil {
+		t.Fatalf("local media: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	stray := filepath.Join(fetcher.MediaDir(), "999", "stray.mp3")
+	if err := os.MkdirAll(filepath.Dir(stray), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stray, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	download(ids[2])
+
+	for i, want := range []bool{true, false, true} {
+		local, err := fetcher.LocalMedia(ctx, ids[i])
+		if err != nil {
+			t.Fatalf("local media: %v", err)
+		}
+		if got := len(local) == 1; got != want {
+			t.Fatalf("entry %d cached = %t, want %t", i, got, want)
+		}
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Fatalf("expected orphaned file to be removed, stat err = %v", err)
+	}
+}
+
+func TestFetcherAutoDownloadsMediaAfterFetch(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newMediaTestFetcher(t, s, 1000, 1<<20)
+	ctx := context.Background()
+
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".mp3") {
+			_, _ = w.Write(make([]byte, 10))
+			return
+		}
+		var items strings.Builder
+		for i := 0; i < mediaRunBatch+5; i++ {
+			fmt.Fprintf(&items, `<item><guid>ep%d</guid><title>Episode %d</title><enclosure url="%s/ep%d.mp3" type="audio/mpeg"/></item>`, i, i, srvURL, i)
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Podcast</title>` + items.String() + `</channel></rss>`))
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	feed := mustCreateFeed(t, s, srv.URL+"/feed.xml")
+	enabled := true
+	if _, err := s.UpdateFeed(ctx, feed.ID, store.UpdateFeedInput{AutoDownload: &enabled}); err != nil {
+		t.Fatalf("enable auto download: %v", err)
+	}
+
+	// Fetches that do not ask for media, like the implicit one before a
+	// listing, leave it alone.
+	if _, err := fetcher.Fetch(ctx, &feed.ID); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if files, err := s.ListMediaFiles(ctx); err != nil || len(files) != 0 {
+		t.Fatalf("plain fetch downloaded %d files, err=%v", len(files), err)
+	}
+
+	// One run downloads at most mediaRunBatch entries; the next picks up
+	// the rest.
+	for run, want := range []int{mediaRunBatch, mediaRunBatch + 5} {
+		if _, err := fetcher.FetchWithOptions(ctx, FetchOptions{FeedID: &feed.ID, DownloadMedia: true}, nil); err != nil {
+			t.Fatalf("fetch %d: %v", run, err)
+		}
+		files, err := s.ListMediaFiles(ctx)
+		if err != nil {
+			t.Fatalf("list media: %v", err)
+		}
+		if len(files) != want {
+			t.Fatalf("after run %d: %d files downloaded, want %d", run, len(files), want)
+		}
+	}
+}
+
+func TestFetcherSkipsRevisionsAfterSanitizerChanges(t *testing.T) {
+	s := newTestStore(t)
+	fetcher := newTestFetcher(s)
+	ctx := context.Background()
+
+	var description atomic.Value
+	description.Store(`<p>Hello <img src="/a.png" width="9000"></p>`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>T</title><link>https://example.com/</link>
+<item><guid>item-1</guid><title>Entry</title><link>https://example.com/1</link><description><![CDATA[` + description.Load().(string) + `]]></description></item>
+</channel></rss>`))
+	}))
+	defer srv.Close()
+	feed := mustCreateFeed(t, s, srv.URL)
+
+	// The entry as an older release stored it: different sanitizer output
+	// and no source hash.
+	id, _, err := s.UpsertEntry(ctx, model.UpsertEntryInput{
+		FeedID:      feed.ID,
+		GUID:        "item-1",
+		URL:         "https://example.com/1",
+		Title:       "Entry",
+		Summary:     "Hello",
+		ContentHTML: `<p>Hello <img src="/a.png" width="9000"></p>`,
+		ContentMD:   "Hello ![](/a.png)",
+	})
+	if err != nil {
+		t.Fatalf("seed entry: %v", err)
+	}
+
+	revisions := func() int {
+		t.Helper()
+		revs, err := s.ListEntryRevisions(ctx, id)
+		if err != nil {
+			t.Fatalf("list revisions: %v", err)
+		}
+		return len(revs) - 1
+	}
+	fetchFeed := func() FetchResult {
+		t.Helper()
+		rep, err := fetcher.Fetch(ctx, &feed.ID)
+		if err != nil || len(rep.Results) != 1 || rep.Results[0].Error != "" {
+			t.Fatalf("fetch: %+v, %v", rep, err)
+		}
+		return rep.Results[0]
+	}
+
+	if result := fetchFeed(); result.Updated != 0 || revisions() != 0 {
+		t.Fatalf("re-fetching an unchanged feed after an upgrade: updated=%d revisions=%d, want none", result.Updated, revisions())
+	}
+	entry, err := s.GetEntry(ctx, id)
+	if err != nil {
+		t.Fatalf("get entry: %v", err)
+	}
+	if !strings.Contains(entry.ContentHTML, "https://example.com/a.png") {
+		t.Fatalf("expected content refreshed with current sanitizer output, got %q", entry.ContentHTML)
+	}
+	if result := fetchFeed(); result.Updated != 0 || revisions() != 0 {
+		t.Fatalf("second fetch: updated=%d revisions=%d, want none", result.Updated, revisions())
+	}
+
+	// A real edit is still archived.
+	description.Store(`<p>Hello, edited</p>`)
+	if result := fetchFeed(); result.Updated != 1 || revisions() != 1 {
+		t.Fatalf("after an edit: updated=%d revisions=%d, want 1 and 1", result.Updated, revisions())
+	}
+}
@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	maxArticleBytes = 8 << 20
+	// fullContentBatch bounds how many articles one feed fetch downloads, so
+	// enabling full content on a large feed backfills over several runs.
+	fullContentBatch = 10
+)
+
+// FetchArticle downloads a web page and returns its main article as
+// sanitized HTML and Markdown. Responses that are not HTML, such as the audio
+// file or PDF a podcast item links to, are rejected rather than parsed.
+func (f *Fetcher) FetchArticle(ctx context.Context, pageURL string) (contentHTML, contentMD string, err error) {
+	release, err := f.hosts.acquire(ctx, feedHost(pageURL))
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html, application/xhtml+xml, */*;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", "", fmt.Errorf("http %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(contentType); err != nil || (mt != "text/html" && mt != "application/xhtml+xml") {
+		return "", "", fmt.Errorf("not an HTML page (Content-Type %q)", contentType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxArticleBytes), contentType)
+	if err != nil {
+		return "", "", fmt.Errorf("decode page: %w", err)
+	}
+	extracted, err := ExtractArticle(body)
+	if err != nil {
+		return "", "", err
+	}
+	contentHTML = SanitizeHTMLWithBase(extracted, resp.Request.URL)
+	contentMD = f.renderer.HTMLToMarkdown(contentHTML)
+	if strings.TrimSpace(contentMD) == "" {
+		return "", "", fmt.Errorf("no article content found")
+	}
+	return contentHTML, contentMD, nil
+}
+
+// fillFullContent extracts articles for the feed's newest entries that have
+// none yet. Failures are counted so a broken page is only retried a few
+// times, and summarized in the returned warning.
+func (f *Fetcher) fillFullContent(ctx context.Context, feed Feed) string {
+	entries, err := f.store.ListEntriesMissingFullContent(ctx, feed.ID, fullContentBatch)
+	if err != nil {
+		return fmt.Sprintf("full content: %v", err)
+	}
+	failed := 0
+	for _, entry := range entries {
+		contentHTML, contentMD, err := f.FetchArticle(ctx, entry.URL)
+		if err != nil {
+			failed++
+			if err := f.store.RecordFullContentFailure(ctx, entry.ID, err.Error()); err != nil {
+				return fmt.Sprintf("full content: %v", err)
+			}
+			continue
+		}
+		if err := f.store.SetEntryFullContent(ctx, entry.ID, contentHTML, contentMD, time.Now()); err != nil {
+			return fmt.Sprintf("full content: %v", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Sprintf("full content: %d of %d articles could not be extracted", failed, len(entries))
+	}
+	return ""
+}
+
+func joinWarnings(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "; " + b
+}
@@ -0,0 +1,118 @@
+package fetch
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultHostConcurrency = 2
+
+// hostLimiter caps concurrent requests per host and spaces out request
+// starts to the same host by a minimum delay. Requests to distinct hosts do
+// not wait on each other.
+type hostLimiter struct {
+	perHost int
+	delay   time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+type hostSlot struct {
+	sem chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newHostLimiter(perHost int, delay time.Duration) *hostLimiter {
+	if perHost < 1 {
+		perHost = defaultHostConcurrency
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return &hostLimiter{perHost: perHost, delay: delay, hosts: make(map[string]*hostSlot)}
+}
+
+func (l *hostLimiter) slot(host string) *hostSlot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &hostSlot{sem: make(chan struct{}, l.perHost)}
+		l.hosts[host] = s
+	}
+	return s
+}
+
+// acquire blocks until a request to host may start. The returned release
+// must be called once the request is done.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	s := l.slot(host)
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-s.sem }
+
+	s.mu.Lock()
+	now := time.Now()
+	start := s.next
+	if start.Before(now) {
+		start = now
+	}
+	s.next = start.Add(l.delay)
+	s.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func feedHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// interleaveByHost reorders feeds round-robin across hosts so that a run of
+// feeds on one host does not occupy every worker while other hosts wait.
+func interleaveByHost(feeds []Feed) []Feed {
+	order := make([]string, 0)
+	byHost := make(map[string][]Feed)
+	for _, feed := range feeds {
+		host := feedHost(feed.URL)
+		if _, ok := byHost[host]; !ok {
+			order = append(order, host)
+		}
+		byHost[host] = append(byHost[host], feed)
+	}
+
+	out := make([]Feed, 0, len(feeds))
+	for len(out) < len(feeds) {
+		for _, host := range order {
+			queue := byHost[host]
+			if len(queue) == 0 {
+				continue
+			}
+			out = append(out, queue[0])
+			byHost[host] = queue[1:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package fetch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// lazySrcAttrs hold the real image URL in lazy-loading markup; the src
+// attribute then carries a placeholder until a script swaps them.
+var lazySrcAttrs = []string{"data-src", "data-lazy-src", "data-original"}
+
+var placeholderImageRegexp = regexp.MustCompile(`(?i)(blank|placeholder|spacer|transparent|pixel|lazy|loading)[^/]*\.(gif|png|svg)$`)
+
+type srcsetCandidate struct {
+	url        string
+	descriptor string
+}
+
+// parseSrcset splits a srcset value into image candidates. URLs may contain
+// commas, so candidates are split the way browsers do rather than on every
+// comma.
+func parseSrcset(v string) []srcsetCandidate {
+	var out []srcsetCandidate
+	for {
+		v = strings.TrimLeft(v, " \t\n\r\f,")
+		if v == "" {
+			return out
+		}
+		end := strings.IndexAny(v, " \t\n\r\f")
+		if end < 0 {
+			end = len(v)
+		}
+		c := srcsetCandidate{url: v[:end]}
+		v = v[end:]
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+		} else {
+			desc, rest, _ := strings.Cut(v, ",")
+			c.descriptor = strings.TrimSpace(desc)
+			v = rest
+		}
+		out = append(out, c)
+	}
+}
+
+func formatSrcset(candidates []srcsetCandidate) string {
+	parts := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.descriptor == "" {
+			parts = append(parts, c.url)
+			continue
+		}
+		parts = append(parts, c.url+" "+c.descriptor)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bestSrcsetCandidate picks the widest candidate, or the highest pixel
+// density when the srcset uses x descriptors.
+func bestSrcsetCandidate(v string) string {
+	best, bestScore := "", -1.0
+	for _, c := range parseSrcset(v) {
+		score := 1.0
+		switch d := strings.ToLower(c.descriptor); {
+		case strings.HasSuffix(d, "w"):
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(d, "w"), 64); err == nil {
+				// Rank widths above any density descriptor.
+				score = 1000 + n
+			}
+		case strings.HasSuffix(d, "x"):
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(d, "x"), 64); err == nil {
+				score = n
+			}
+		}
+		if score > bestScore {
+			best, bestScore = c.url, score
+		}
+	}
+	return best
+}
+
+func isPlaceholderSrc(src string) bool {
+	src = strings.TrimSpace(src)
+	if src == "" || strings.HasPrefix(strings.ToLower(src), "data:") {
+		return true
+	}
+	path, _, _ := strings.Cut(src, "?")
+	return placeholderImageRegexp.MatchString(path)
+}
+
+// normalizeImage rewrites lazy-loading image markup so src holds the real
+// image: a data-src style attribute wins, then the best srcset candidate when
+// src is missing or a placeholder. It returns the resulting src, and reports
+// drop for tracking pixels.
+func normalizeImage(attrs []html.Attribute) (out []html.Attribute, src string, drop bool) {
+	get := func(key string) string {
+		for _, a := range attrs {
+			if strings.EqualFold(strings.TrimSpace(a.Key), key) {
+				return strings.TrimSpace(a.Val)
+			}
+		}
+		return ""
+	}
+	if isTrackingPixel(get("width"), get("height")) {
+		return nil, "", true
+	}
+
+	src = get("src")
+	srcset := get("srcset")
+	if v := get("data-srcset"); v != "" {
+		srcset = v
+	}
+	lazy := ""
+	for _, key := range lazySrcAttrs {
+		if v := get(key); v != "" && !strings.HasPrefix(strings.ToLower(v), "data:") {
+			lazy = v
+			break
+		}
+	}
+	switch {
+	case lazy != "":
+		src = lazy
+	case isPlaceholderSrc(src) && srcset != "":
+		src = bestSrcsetCandidate(srcset)
+	}
+
+	out = make([]html.Attribute, 0, len(attrs))
+	hasSrc, hasSrcset := false, false
+	for _, a := range attrs {
+		switch strings.ToLower(strings.TrimSpace(a.Key)) {
+		case "src":
+			a.Val, hasSrc = src, true
+		case "srcset":
+			a.Val, hasSrcset = srcset, true
+		case "data-src", "data-lazy-src", "data-original", "data-srcset":
+			continue
+		}
+		out = append(out, a)
+	}
+	if !hasSrc && src != "" {
+		out = append(out, html.Attribute{Key: "src", Val: src})
+	}
+	if !hasSrcset && srcset != "" {
+		out = append(out, html.Attribute{Key: "srcset", Val: srcset})
+	}
+	return out, src, false
+}
+
+// isTrackingPixel reports images sized 1x1 or smaller, which are beacons
+// rather than content.
+func isTrackingPixel(width, height string) bool {
+	w, errW := strconv.Atoi(strings.TrimSuffix(width, "px"))
+	h, errH := strconv.Atoi(strings.TrimSuffix(height, "px"))
+	return errW == nil && errH == nil && w <= 1 && h <= 1
+}
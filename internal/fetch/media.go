@@ -0,0 +1,399 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	mediaKindEnclosure = "enclosure"
+	mediaKindImage     = "image"
+	// mediaRunBatch bounds how many entries one fetch run downloads media
+	// for, across all feeds.
+	mediaRunBatch = 20
+	// autoDownloadWindow limits automatic downloads to recent entries, so
+	// enabling it on a feed with a long archive does not pull every episode.
+	autoDownloadWindow = 7 * 24 * time.Hour
+	// Media downloads are exempt from the feed HTTP timeout, which is sized
+	// for small documents, but still bounded.
+	mediaDownloadTimeout = 30 * time.Minute
+	partialMediaPrefix   = ".partial-"
+)
+
+type mediaSource struct {
+	url      string
+	kind     string
+	mimeType string
+}
+
+// MediaDir is where downloaded media are kept: a "media" directory next to
+// the database.
+func (f *Fetcher) MediaDir() string {
+	return filepath.Join(filepath.Dir(f.cfg.DBPath), "media")
+}
+
+// DownloadEntryMedia stores an entry's enclosures and inline images in the
+// media cache. Files already cached are kept; per-file failures, including
+// files over the size limit, are reported in the result rather than as an
+// error, and leave the entry to be retried by later automatic downloads.
+// The cache is trimmed to its size limit afterwards.
+func (f *Fetcher) DownloadEntryMedia(ctx context.Context, entryID int64) (MediaDownloadResult, error) {
+	result := MediaDownloadResult{EntryID: entryID}
+	entry, err := f.store.GetEntry(ctx, entryID)
+	if err != nil {
+		return result, err
+	}
+	existing, err := f.store.ListEntryMedia(ctx, entryID)
+	if err != nil {
+		return result, err
+	}
+	cached := make(map[string]MediaFile, len(existing))
+	for _, m := range existing {
+		cached[m.URL] = m
+	}
+
+	for _, src := range entryMediaSources(entry) {
+		if m, ok := cached[src.url]; ok {
+			if _, err := os.Stat(filepath.Join(f.MediaDir(), m.Path)); err == nil {
+				result.Cached++
+				continue
+			}
+		}
+		m, err := f.downloadMedia(ctx, entryID, src)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", src.url, err))
+			continue
+		}
+		result.Downloaded++
+		result.Bytes += m.Size
+	}
+
+	now := time.Now()
+	if len(result.Errors) > 0 {
+		err = f.store.RecordMediaFailure(ctx, entryID)
+	} else {
+		err = f.store.SetEntryMediaFetchedAt(ctx, entryID, now)
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := f.store.TouchEntryMedia(ctx, entryID, now); err != nil {
+		return result, err
+	}
+	if err := f.evictMedia(ctx); err != nil {
+		return result, fmt.Errorf("evict media cache: %w", err)
+	}
+	return result, nil
+}
+
+// LocalMedia maps the remote URLs of an entry's downloaded media to their
+// absolute paths on disk, skipping files that have gone missing, and marks
+// them as recently used.
+func (f *Fetcher) LocalMedia(ctx context.Context, entryID int64) (map[string]string, error) {
+	files, err := f.store.ListEntryMedia(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]string, len(files))
+	for _, m := range files {
+		abs := filepath.Join(f.MediaDir(), m.Path)
+		if _, err := os.Stat(abs); err == nil {
+			local[m.URL] = abs
+		}
+	}
+	if len(local) > 0 {
+		if err := f.store.TouchEntryMedia(ctx, entryID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return local, nil
+}
+
+// autoDownloadMedia downloads media for the fetched feeds that have
+// automatic downloads enabled. It runs once every feed has been fetched, so
+// long downloads do not hold up the fetch, and handles at most mediaRunBatch
+// entries per run. Failures are added to the feeds' result warnings.
+func (f *Fetcher) autoDownloadMedia(ctx context.Context, feeds []Feed, results []FetchResult) {
+	auto := make(map[int64]bool, len(feeds))
+	for _, feed := range feeds {
+		auto[feed.ID] = feed.AutoDownload
+	}
+	budget := mediaRunBatch
+	for i := range results {
+		result := &results[i]
+		if !auto[result.FeedID] || result.Error != "" {
+			continue
+		}
+		if budget <= 0 || ctx.Err() != nil {
+			return
+		}
+		handled, warning := f.downloadFeedMedia(ctx, result.FeedID, budget)
+		budget -= handled
+		if warning != "" {
+			result.Warning = joinWarnings(result.Warning, warning)
+		}
+	}
+}
+
+// downloadFeedMedia downloads media for up to limit of the feed's recent
+// entries that have not been handled yet. It returns how many entries it
+// handled and a warning that summarizes failures.
+func (f *Fetcher) downloadFeedMedia(ctx context.Context, feedID int64, limit int) (int, string) {
+	ids, err := f.store.ListEntriesMissingMedia(ctx, feedID, time.Now().Add(-autoDownloadWindow), limit)
+	if err != nil {
+		return 0, fmt.Sprintf("media download: %v", err)
+	}
+	failed := 0
+	for i, id := range ids {
+		result, err := f.DownloadEntryMedia(ctx, id)
+		if err != nil {
+			return i, fmt.Sprintf("media download: %v", err)
+		}
+		failed += len(result.Errors)
+	}
+	if failed > 0 {
+		return len(ids), fmt.Sprintf("media download: %d file(s) could not be downloaded", failed)
+	}
+	return len(ids), ""
+}
+
+func (f *Fetcher) downloadMedia(ctx context.Context, entryID int64, src mediaSource) (MediaFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, mediaDownloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.url, nil)
+	if err != nil {
+		return MediaFile{}, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+
+	// The host slot only spaces out request starts: it is released once the
+	// response arrives, so a long download does not hold up feed fetches to
+	// the same host.
+	release, err := f.hosts.acquire(ctx, feedHost(src.url))
+	if err != nil {
+		return MediaFile{}, err
+	}
+	resp, err := f.mediaClient.Do(req)
+	release()
+	if err != nil {
+		return MediaFile{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return MediaFile{}, fmt.Errorf("http %d", resp.StatusCode)
+	}
+	limit := f.cfg.MediaMaxFileSize
+	if resp.ContentLength > limit {
+		return MediaFile{}, fmt.Errorf("%d bytes exceeds the %d byte file size limit", resp.ContentLength, limit)
+	}
+
+	dirName := strconv.FormatInt(entryID, 10)
+	dir := filepath.Join(f.MediaDir(), dirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return MediaFile{}, err
+	}
+	tmp, err := os.CreateTemp(dir, partialMediaPrefix+"*")
+	if err != nil {
+		return MediaFile{}, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, limit+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return MediaFile{}, err
+	}
+	if n > limit {
+		return MediaFile{}, fmt.Errorf("file exceeds the %d byte size limit", limit)
+	}
+
+	mimeType := src.mimeType
+	if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mt != "application/octet-stream" {
+		mimeType = mt
+	}
+	m := MediaFile{
+		EntryID:  entryID,
+		URL:      src.url,
+		Path:     filepath.Join(dirName, mediaFileName(src.url, mimeType)),
+		Kind:     src.kind,
+		MimeType: mimeType,
+		Size:     n,
+	}
+
+	// Held while the file becomes visible so a concurrent eviction never
+	// sees it on disk without its database row.
+	f.mediaMu.Lock()
+	defer f.mediaMu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(f.MediaDir(), m.Path)); err != nil {
+		return MediaFile{}, err
+	}
+	if err := f.store.AddMediaFile(ctx, m); err != nil {
+		_ = os.Remove(filepath.Join(f.MediaDir(), m.Path))
+		return MediaFile{}, err
+	}
+	return m, nil
+}
+
+// evictMedia deletes the least recently used files until the cache fits its
+// size limit, and removes files left behind by deleted entries.
+func (f *Fetcher) evictMedia(ctx context.Context) error {
+	f.mediaMu.Lock()
+	defer f.mediaMu.Unlock()
+
+	files, err := f.store.ListMediaFiles(ctx)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, m := range files {
+		total += m.Size
+	}
+	keep := make(map[string]struct{}, len(files))
+	for _, m := range files {
+		if total > f.cfg.MediaCacheSize {
+			if err := os.Remove(filepath.Join(f.MediaDir(), m.Path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if err := f.store.DeleteMediaFile(ctx, m.ID); err != nil {
+				return err
+			}
+			total -= m.Size
+			continue
+		}
+		keep[m.Path] = struct{}{}
+	}
+	return removeOrphanMedia(f.MediaDir(), keep)
+}
+
+func removeOrphanMedia(root string, keep map[string]struct{}) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if p != root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), partialMediaPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := keep[rel]; !ok {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		// Only succeeds once an entry's directory is empty.
+		_ = os.Remove(dir)
+	}
+	return nil
+}
+
+// entryMediaSources lists an entry's enclosures followed by the images in
+// its feed and full-article content.
+func entryMediaSources(entry Entry) []mediaSource {
+	var out []mediaSource
+	seen := make(map[string]struct{})
+	add := func(src mediaSource) {
+		u, err := url.Parse(src.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		if _, ok := seen[src.url]; ok {
+			return
+		}
+		seen[src.url] = struct{}{}
+		out = append(out, src)
+	}
+	for _, enc := range entry.Enclosures {
+		add(mediaSource{url: enc.URL, kind: mediaKindEnclosure, mimeType: enc.MimeType})
+	}
+	for _, content := range []string{entry.ContentHTML, entry.FullContentHTML} {
+		for _, src := range htmlImageURLs(content) {
+			add(mediaSource{url: src, kind: mediaKindImage})
+		}
+	}
+	return out
+}
+
+func htmlImageURLs(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	doc, err := html.Parse(strings.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	var urls []string
+	walkElements(doc, func(n *html.Node) {
+		if n.Data == "img" {
+			if src := strings.TrimSpace(attrValue(n, "src")); src != "" {
+				urls = append(urls, src)
+			}
+		}
+	})
+	return urls
+}
+
+// mediaFileName derives a stable file name from the URL, keeping its
+// extension (or one implied by the MIME type) so other programs can open it.
+func mediaFileName(rawURL, mimeType string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	name := hex.EncodeToString(sum[:8])
+	ext := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	if !isPlainExtension(ext) {
+		ext = ""
+		if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return name + ext
+}
+
+func isPlainExtension(ext string) bool {
+	if len(ext) < 2 || len(ext) > 6 || ext[0] != '.' {
+		return false
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
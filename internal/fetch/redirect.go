@@ -0,0 +1,62 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/odysseus0/feed/internal/store"
+)
+
+const maxRedirects = 10
+
+type redirectTraceKey struct{}
+
+// redirectTrace records the last location reached through an unbroken chain
+// of permanent (301/308) redirects. A temporary hop anywhere in the chain
+// means the original URL is still authoritative.
+type redirectTrace struct {
+	permanentURL string
+	broken       bool
+}
+
+func withRedirectTrace(ctx context.Context) (context.Context, *redirectTrace) {
+	trace := &redirectTrace{}
+	return context.WithValue(ctx, redirectTraceKey{}, trace), trace
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	trace, _ := req.Context().Value(redirectTraceKey{}).(*redirectTrace)
+	if trace == nil || trace.broken || req.Response == nil {
+		return nil
+	}
+	switch req.Response.StatusCode {
+	case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		trace.permanentURL = req.URL.String()
+	default:
+		trace.broken = true
+	}
+	return nil
+}
+
+// recordPermanentRedirect stores the feed's new location after a successful
+// fetch through permanent redirects. A URL already used by another feed is
+// left alone and reported as a warning instead of failing the fetch.
+func (f *Fetcher) recordPermanentRedirect(ctx context.Context, feed Feed, trace *redirectTrace, result *FetchResult) {
+	if trace.permanentURL == "" || trace.permanentURL == feed.URL {
+		return
+	}
+	err := f.store.UpdateFeedURL(ctx, feed.ID, trace.permanentURL)
+	switch {
+	case err == nil:
+		result.MovedTo = trace.permanentURL
+	case errors.Is(err, store.ErrConflict):
+		result.Warning = fmt.Sprintf("permanent redirect not saved: %v", err)
+	default:
+		result.Warning = fmt.Sprintf("failed to save permanent redirect to %s: %v", trace.permanentURL, err)
+	}
+}
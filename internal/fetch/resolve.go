@@ -0,0 +1,99 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// resolveReference resolves ref against base, leaving it unchanged when
+// there is no base or ref does not parse.
+func resolveReference(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveSrcset resolves each image candidate URL in a srcset value,
+// keeping the width or density descriptors.
+func resolveSrcset(base *url.URL, v string) string {
+	if base == nil {
+		return v
+	}
+	candidates := parseSrcset(v)
+	for i := range candidates {
+		candidates[i].url = resolveReference(base, candidates[i].url)
+	}
+	return formatSrcset(candidates)
+}
+
+// absoluteURL resolves ref against base and returns it only if the result is
+// an absolute http(s) URL usable as a base for further resolution.
+func absoluteURL(base *url.URL, ref string) *url.URL {
+	u, err := url.Parse(resolveReference(base, ref))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// rssItemBases returns the xml:base in effect for each RSS <item>, in
+// document order, with nil for items that have none. gofeed applies xml:base
+// to Atom documents itself but ignores it in RSS.
+func rssItemBases(data []byte, docURL *url.URL) []*url.URL {
+	if !bytes.Contains(data, []byte("xml:base")) {
+		return nil
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+
+	type frame struct {
+		name string
+		base *url.URL
+	}
+	stack := []frame{{}}
+	var bases []*url.URL
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// io.EOF or a malformed document: keep what was found so far.
+			return bases
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := stack[len(stack)-1]
+			base := parent.base
+			for _, a := range t.Attr {
+				if a.Name.Local == "base" && (a.Name.Space == xmlNamespace || a.Name.Space == "xml") {
+					resolveAgainst := base
+					if resolveAgainst == nil {
+						resolveAgainst = docURL
+					}
+					if u := absoluteURL(resolveAgainst, a.Value); u != nil {
+						base = u
+					}
+				}
+			}
+			if t.Name.Local == "item" && (parent.name == "channel" || parent.name == "RDF") {
+				bases = append(bases, base)
+			}
+			stack = append(stack, frame{name: t.Name.Local, base: base})
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
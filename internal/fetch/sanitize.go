@@ -1,6 +1,7 @@
 package fetch
 
 import (
+	"net/url"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -22,6 +23,12 @@ var blockedTags = map[string]struct{}{
 }
 
 func SanitizeHTML(raw string) string {
+	return SanitizeHTMLWithBase(raw, nil)
+}
+
+// SanitizeHTMLWithBase sanitizes like SanitizeHTML and also rewrites relative
+// URLs in link, image and media attributes to absolute ones against base.
+func SanitizeHTMLWithBase(raw string, base *url.URL) string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return ""
@@ -39,7 +46,7 @@ func SanitizeHTML(raw string) string {
 
 	var b strings.Builder
 	for c := body.FirstChild; c != nil; c = c.NextSibling {
-		sanitized := sanitizeNode(c)
+		sanitized := sanitizeNode(c, base)
 		if sanitized == nil {
 			continue
 		}
@@ -63,7 +70,7 @@ func findBodyNode(n *html.Node) *html.Node {
 	return nil
 }
 
-func sanitizeNode(n *html.Node) *html.Node {
+func sanitizeNode(n *html.Node, base *url.URL) *html.Node {
 	if n == nil {
 		return nil
 	}
@@ -77,19 +84,44 @@ func sanitizeNode(n *html.Node) *html.Node {
 		if _, blocked := blockedTags[tag]; blocked {
 			return nil
 		}
+		attrs := n.Attr
+		if tag == "img" {
+			var src string
+			var drop bool
+			attrs, src, drop = normalizeImage(attrs)
+			if drop {
+				return nil
+			}
+			if !isSafeURL(src, tag, "src") {
+				src = ""
+			}
+			if src == "" {
+				// Nothing safe to display; keep the description for readers.
+				if alt := strings.TrimSpace(attrValue(n, "alt")); alt != "" {
+					return &html.Node{Type: html.TextNode, Data: alt}
+				}
+				return nil
+			}
+		}
 		clone := &html.Node{Type: html.ElementNode, Data: n.Data, Namespace: n.Namespace}
-		for _, a := range n.Attr {
+		for _, a := range attrs {
 			k := strings.ToLower(strings.TrimSpace(a.Key))
 			if k == "" || strings.HasPrefix(k, "on") || k == "style" || k == "srcdoc" {
 				continue
 			}
-			if isURLAttr(k) && !isSafeURL(a.Val, tag, k) {
-				continue
+			if isURLAttr(k) {
+				if !isSafeURL(a.Val, tag, k) {
+					continue
+				}
+				a.Val = resolveReference(base, a.Val)
+			}
+			if k == "srcset" {
+				a.Val = resolveSrcset(base, a.Val)
 			}
 			clone.Attr = append(clone.Attr, a)
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			child := sanitizeNode(c)
+			child := sanitizeNode(c, base)
 			if child != nil {
 				clone.AppendChild(child)
 			}
@@ -98,7 +130,7 @@ func sanitizeNode(n *html.Node) *html.Node {
 	case html.DocumentNode:
 		clone := &html.Node{Type: html.DocumentNode}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			child := sanitizeNode(c)
+			child := sanitizeNode(c, base)
 			if child != nil {
 				clone.AppendChild(child)
 			}
@@ -107,7 +139,7 @@ func sanitizeNode(n *html.Node) *html.Node {
 	default:
 		clone := &html.Node{Type: n.Type, Data: n.Data, Namespace: n.Namespace}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			child := sanitizeNode(c)
+			child := sanitizeNode(c, base)
 			if child != nil {
 				clone.AppendChild(child)
 			}
@@ -1,6 +1,7 @@
 package fetch
 
 import (
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -26,3 +27,92 @@ func TestSanitizeHTML_PreservesSafeMarkup(t *testing.T) {
 		t.Fatalf("safe link should be preserved, got: %s", out)
 	}
 }
+
+func TestSanitizeHTMLWithBase_ResolvesRelativeURLs(t *testing.T) {
+	base, err := url.Parse("https://example.com/blog/post/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `<p><a href="../other">x</a><a href="mailto:me@example.com">m</a><img src="/i.png" srcset="small.png 1x, /large.png 2x"><img src="data:image/png;base64,abcd"></p>`
+	out := SanitizeHTMLWithBase(in, base)
+
+	for _, want := range []string{
+		`href="https://example.com/blog/other"`,
+		`href="mailto:me@example.com"`,
+		`src="https://example.com/i.png"`,
+		`srcset="https://example.com/blog/post/small.png 1x, https://example.com/large.png 2x"`,
+		`src="data:image/png;base64,abcd"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+func TestSanitizeHTML_PromotesLazyImages(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "data-src",
+			in:   `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="https://example.com/real.jpg" alt="Real">`,
+			want: `<img src="https://example.com/real.jpg" alt="Real"/>`,
+		},
+		{
+			name: "data-lazy-src over placeholder file",
+			in:   `<img src="/img/blank.gif" data-lazy-src="https://example.com/lazy.png">`,
+			want: `<img src="https://example.com/lazy.png"/>`,
+		},
+		{
+			name: "best srcset candidate by width",
+			in:   `<img srcset="https://example.com/s.jpg 320w, https://example.com/l.jpg 1280w, https://example.com/m.jpg 640w" alt="Photo">`,
+			want: `<img srcset="https://example.com/s.jpg 320w, https://example.com/l.jpg 1280w, https://example.com/m.jpg 640w" alt="Photo" src="https://example.com/l.jpg"/>`,
+		},
+		{
+			name: "best srcset candidate by density",
+			in:   `<img src="https://example.com/placeholder.png" srcset="https://example.com/a.jpg, https://example.com/a@2x.jpg 2x">`,
+			want: `<img src="https://example.com/a@2x.jpg" srcset="https://example.com/a.jpg, https://example.com/a@2x.jpg 2x"/>`,
+		},
+		{
+			name: "real src is kept",
+			in:   `<img src="https://example.com/keep.jpg" srcset="https://example.com/big.jpg 2x">`,
+			want: `<img src="https://example.com/keep.jpg" srcset="https://example.com/big.jpg 2x"/>`,
+		},
+		{
+			name: "tracking pixel dropped",
+			in:   `<p>Text<img src="https://tracker.example/p.gif" width="1" height="1"></p>`,
+			want: `<p>Text</p>`,
+		},
+		{
+			name: "alt kept when no source",
+			in:   `<p><img data-src="data:image/gif;base64,AAAA" alt="Diagram of the pipeline"></p>`,
+			want: `<p>Diagram of the pipeline</p>`,
+		},
+		{
+			name: "alt kept when promoted source is unsafe",
+			in:   `<p><img src="/img/blank.gif" data-src="javascript:alert(1)" alt="Chart"></p>`,
+			want: `<p>Chart</p>`,
+		},
+		{
+			name: "alt kept when srcset candidate is unsafe",
+			in:   `<p><img srcset="javascript:alert(1) 2x" alt="Photo"></p>`,
+			want: `<p>Photo</p>`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SanitizeHTML(tc.in); got != tc.want {
+				t.Fatalf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSrcsetKeepsCommasInURLs(t *testing.T) {
+	got := bestSrcsetCandidate("https://img.example/w_100,h_50/a.jpg 100w, https://img.example/w_800,h_400/a.jpg 800w")
+	if got != "https://img.example/w_800,h_400/a.jpg" {
+		t.Fatalf("unexpected best candidate %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+)
+
+const (
+	minPollInterval     = 15 * time.Minute
+	defaultPollInterval = time.Hour
+	maxPollInterval     = 24 * time.Hour
+	baseErrorBackoff    = 15 * time.Minute
+	maxRetryAfter       = 7 * 24 * time.Hour
+)
+
+// pollInterval picks the delay before the next scheduled fetch after a
+// successful one: half the feed's observed posting gap, but never sooner than
+// the server's cache lifetime, clamped to [minPollInterval, maxPollInterval].
+func pollInterval(postingGap, cacheTTL time.Duration) time.Duration {
+	interval := defaultPollInterval
+	if postingGap > 0 {
+		interval = postingGap / 2
+	}
+	if cacheTTL > interval {
+		interval = cacheTTL
+	}
+	return clampDuration(interval, minPollInterval, maxPollInterval)
+}
+
+// errorBackoff doubles the retry delay for each consecutive failure, starting
+// at baseErrorBackoff and capped at maxPollInterval.
+func errorBackoff(errorCount int) time.Duration {
+	if errorCount < 1 {
+		errorCount = 1
+	}
+	d := baseErrorBackoff
+	for i := 1; i < errorCount && d < maxPollInterval; i++ {
+		d *= 2
+	}
+	return clampDuration(d, baseErrorBackoff, maxPollInterval)
+}
+
+// cacheLifetime reads how long the response may be cached from
+// Cache-Control max-age, falling back to Expires. It returns zero when the
+// response is not cacheable or carries no freshness information.
+func cacheLifetime(h http.Header, now time.Time) time.Duration {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			secs, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`))
+			if err != nil || secs <= 0 {
+				return 0
+			}
+			return time.Duration(secs) * time.Second
+		}
+	}
+	if raw := strings.TrimSpace(h.Get("Expires")); raw != "" {
+		if expires, err := http.ParseTime(raw); err == nil && expires.After(now) {
+			return expires.Sub(now)
+		}
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After header in either delay-seconds or
+// HTTP-date form and returns the absolute time before which the server asked
+// not to be contacted, capped at maxRetryAfter from now.
+func parseRetryAfter(raw string, now time.Time) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	var until time.Time
+	if secs, err := strconv.Atoi(raw); err == nil {
+		if secs <= 0 {
+			return time.Time{}, false
+		}
+		until = now.Add(time.Duration(secs) * time.Second)
+	} else if t, err := http.ParseTime(raw); err == nil {
+		until = t
+	} else {
+		return time.Time{}, false
+	}
+	if !until.After(now) {
+		return time.Time{}, false
+	}
+	if limit := now.Add(maxRetryAfter); until.After(limit) {
+		until = limit
+	}
+	return until, true
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+// dueFeeds drops feeds that are not yet due: those still inside their
+// per-feed minimum refresh interval, and those whose scheduled next fetch
+// time has not arrived.
+func dueFeeds(feeds []Feed, now time.Time) []Feed {
+	out := make([]Feed, 0, len(feeds))
+	for _, feed := range feeds {
+		if store.FeedDue(feed, now) {
+			out = append(out, feed)
+		}
+	}
+	return out
+}
+
+// withoutRetryAfter drops feeds whose server asked us to wait via
+// Retry-After. Unlike dueFeeds this applies even to forced fetches.
+func withoutRetryAfter(feeds []Feed, now time.Time) []Feed {
+	out := make([]Feed, 0, len(feeds))
+	for _, feed := range feeds {
+		if !store.FeedRetryPending(feed, now) {
+			out = append(out, feed)
+		}
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package mcp
+
+import (
+	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/fetch"
+	"github.com/odysseus0/feed/internal/model"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+type Config = config.Config
+type Store = store.Store
+type Fetcher = fetch.Fetcher
+type Entry = model.Entry
+type Feed = model.Feed
+type Enclosure = model.Enclosure
+type Stats = model.Stats
+type FetchResult = model.FetchResult
+type FetchReport = model.FetchReport
+type EntryListOptions = model.EntryListOptions
+type FeedListOptions = model.FeedListOptions
+type FetchOptions = model.FetchOptions
+type SearchOptions = model.SearchOptions
+type AddFeedResponse = model.AddFeedResponse
+type BatchUpdateEntriesResponse = model.BatchUpdateEntriesResponse
+type UpdateEntriesInput = model.UpdateEntriesInput
@@ -0,0 +1,177 @@
+// Package mcp serves the store to agents over the Model Context Protocol,
+// using newline-delimited JSON-RPC 2.0 on a reader and writer (stdio).
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// protocolVersion is the newest MCP revision this server speaks. Clients
+// asking for an older supported revision get that one instead.
+const protocolVersion = "2025-06-18"
+
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+const instructions = "Tools for a local RSS/Atom reader. list_entries returns unread entries by default " +
+	"(fetching feeds first if they are stale); read one with get_entry. Page with next_cursor as after. " +
+	"Only mark entries read when the user asks."
+
+// Server answers MCP requests with the same store and fetcher the CLI uses.
+type Server struct {
+	store   *Store
+	fetcher *Fetcher
+	cfg     Config
+	version string
+	tools   []tool
+}
+
+func New(s *Store, f *Fetcher, cfg Config, version string) *Server {
+	srv := &Server{store: s, fetcher: f, cfg: cfg, version: version}
+	srv.tools = srv.toolList()
+	return srv
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Serve handles one JSON-RPC message per line of r, writing responses to w,
+// until r reaches EOF. Notifications get no response.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(ctx, line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return fmt.Errorf("write response: %w", err)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read request: %w", err)
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, data []byte) *response {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(json.RawMessage("null"), codeParseError, fmt.Sprintf("parse error: %v", err))
+	}
+	notification := len(req.ID) == 0
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if notification {
+			return nil
+		}
+		return errorResponse(req.ID, codeInvalidRequest, "invalid request: expected a JSON-RPC 2.0 method call")
+	}
+	if notification {
+		return nil
+	}
+
+	var result any
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, err.Error())
+		}
+		version := protocolVersion
+		if slices.Contains(supportedVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		result = initializeResult{
+			ProtocolVersion: version,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      implementation{Name: "feed", Version: s.version},
+			Instructions:    instructions,
+		}
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = map[string]any{"tools": s.tools}
+	case "tools/call":
+		var params callToolParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, err.Error())
+		}
+		i := slices.IndexFunc(s.tools, func(t tool) bool { return t.Name == params.Name })
+		if i < 0 {
+			return errorResponse(req.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name))
+		}
+		result = s.tools[i].run(ctx, params.Arguments)
+	default:
+		return errorResponse(req.ID, codeMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid params: %v", err)
+	}
+	return nil
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/fetch"
+	"github.com/odysseus0/feed/internal/model"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+func newTestServer(t *testing.T) (*Server, *store.Store) {
+	t.Helper()
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "feed.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s := store.NewStore(db)
+	cfg := config.Config{HTTPTimeout: 5 * time.Second, FetchConcurrency: 2, UserAgent: "feed-test/1.0", StaleAfter: time.Hour}
+	return New(s, fetch.NewFetcher(s, fetch.NewRenderer(), cfg), cfg, "test"), s
+}
+
+type testResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// session sends each message on its own line and returns the responses.
+func session(t *testing.T, srv *Server, messages ...string) []testResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), strings.NewReader(strings.Join(messages, "\n")), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var responses []testResponse
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp testResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func callTool(t *testing.T, srv *Server, name, args string, out any) callToolResult {
+	t.Helper()
+	resps := session(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`)
+	if len(resps) != 1 || resps[0].Error != nil {
+		t.Fatalf("%s: unexpected responses %+v", name, resps)
+	}
+	var result struct {
+		callToolResult
+		StructuredContent json.RawMessage `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &result); err != nil {
+		t.Fatalf("%s: decode result: %v", name, err)
+	}
+	if out != nil && !result.IsError {
+		if err := json.Unmarshal(result.StructuredContent, out); err != nil {
+			t.Fatalf("%s: decode structured content: %v", name, err)
+		}
+	}
+	return result.callToolResult
+}
+
+func TestMCPProtocol(t *testing.T) {
+	srv, _ := newTestServer(t)
+	resps := session(t, srv,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`,
+		`not json`,
+	)
+	if len(resps) != 6 {
+		t.Fatalf("expected 6 responses (none for the notification), got %d: %+v", len(resps), resps)
+	}
+
+	var init initializeResult
+	if err := json.Unmarshal(resps[0].Result, &init); err != nil || init.ProtocolVersion != "2025-03-26" || init.ServerInfo.Name != "feed" {
+		t.Fatalf("unexpected initialize result %s: %v", resps[0].Result, err)
+	}
+
+	var list struct {
+		Tools []struct {
+			Name         string `json:"name"`
+			InputSchema  schema `json:"inputSchema"`
+			OutputSchema schema `json:"outputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(resps[1].Result, &list); err != nil {
+		t.Fatalf("decode tools/list: %v", err)
+	}
+	names := make([]string, 0, len(list.Tools))
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" || tool.OutputSchema["type"] != "object" {
+			t.Fatalf("tool %s: schemas must be objects, got %v and %v", tool.Name, tool.InputSchema, tool.OutputSchema)
+		}
+		if tool.Name == "get_entry" {
+			if req, _ := tool.InputSchema["required"].([]any); len(req) != 1 || req[0] != "id" {
+				t.Fatalf("get_entry required = %v, want [id]", tool.InputSchema["required"])
+			}
+		}
+	}
+	want := "list_entries get_entry search_entries list_feeds get_stats update_entries add_feed fetch_feeds"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("tools = %q, want %q", got, want)
+	}
+
+	if string(resps[2].Result) != "{}" {
+		t.Fatalf("ping result = %s, want {}", resps[2].Result)
+	}
+	for i, code := range []int{codeMethodNotFound, codeInvalidParams, codeParseError} {
+		if resps[3+i].Error == nil || resps[3+i].Error.Code != code {
+			t.Fatalf("response %d: want error code %d, got %+v", 3+i, code, resps[3+i])
+		}
+	}
+}
+
+func TestMCPTools(t *testing.T) {
+	srv, s := newTestServer(t)
+	ctx := context.Background()
+	feed, _, err := s.CreateFeed(ctx, "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if _, _, err := s.UpsertEntries(ctx, []model.UpsertEntryInput{
+		{FeedID: feed.ID, GUID: "a", Title: "Rust async", ContentHTML: "<p>tokio</p>", ContentMD: "tokio", PublishedAt: &jan},
+		{FeedID: feed.ID, GUID: "b", Title: "Go generics", ContentHTML: "<p>type params</p>", ContentMD: "type params", PublishedAt: &feb},
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	var page entriesResult
+	callTool(t, srv, "list_entries", `{"limit":1,"no_fetch":true}`, &page)
+	if len(page.Entries) != 1 || page.Entries[0].Title != "Go generics" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	callTool(t, srv, "list_entries", `{"limit":1,"no_fetch":true,"after":"`+page.NextCursor+`"}`, &page)
+	if len(page.Entries) != 1 || page.Entries[0].Title != "Rust async" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+	rustID := page.Entries[0].ID
+
+	var entry entryContent
+	callTool(t, srv, "get_entry", `{"id":`+strconv.FormatInt(rustID, 10)+`}`, &entry)
+	if entry.Content != "tokio" || entry.FeedTitle == "" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	var updated BatchUpdateEntriesResponse
+	callTool(t, srv, "update_entries", `{"ids":[`+strconv.FormatInt(rustID, 10)+`],"read":true}`, &updated)
+	if updated.Updated != 1 || updated.Read == nil || !*updated.Read {
+		t.Fatalf("unexpected update: %+v", updated)
+	}
+	var stats Stats
+	callTool(t, srv, "get_stats", `{}`, &stats)
+	if stats.Total != 2 || stats.Unread != 1 || stats.Feeds != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	var found entriesResult
+	callTool(t, srv, "search_entries", `{"query":"generics"}`, &found)
+	if len(found.Entries) != 1 || found.Entries[0].Title != "Go generics" || found.NextCursor != "" {
+		t.Fatalf("unexpected search results: %+v", found)
+	}
+
+	var feeds feedsResult
+	callTool(t, srv, "list_feeds", `null`, &feeds)
+	if len(feeds.Feeds) != 1 || feeds.Feeds[0].UnreadCount != 1 {
+		t.Fatalf("unexpected feeds: %+v", feeds)
+	}
+
+	for _, tc := range []struct{ name, args, want string }{
+		{"get_entry", `{"id":999}`, "Error [not-found]"},
+		{"get_entry", `{"id":"x"}`, "Error [invalid-input]"},
+		{"list_entries", `{"bogus":1}`, "Error [invalid-input]"},
+		{"update_entries", `{"ids":[1]}`, "Error [invalid-input]"},
+		{"search_entries", `{}`, "Error [invalid-input]"},
+	} {
+		res := callTool(t, srv, tc.name, tc.args, nil)
+		if !res.IsError || len(res.Content) != 1 || !strings.HasPrefix(res.Content[0].Text, tc.want) {
+			t.Fatalf("%s %s: want %s tool error, got %+v", tc.name, tc.args, tc.want, res)
+		}
+	}
+}
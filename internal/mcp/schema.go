@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schema is a JSON Schema document.
+type schema = map[string]any
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor derives a JSON Schema from a Go type and its json tags, so tool
+// schemas cannot drift from the values the tools decode and return. Fields
+// without omitempty are required, and a desc tag becomes the description.
+func schemaFor(t reflect.Type) schema {
+	if t == timeType {
+		return schema{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem())
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		props := schema{}
+		required := make([]string, 0)
+		addFields(t, props, &required)
+		out := schema{"type": "object", "properties": props}
+		if len(required) > 0 {
+			out["required"] = required
+		}
+		return out
+	default:
+		return schema{}
+	}
+}
+
+// addFields adds the JSON properties of struct type t, flattening embedded
+// structs the way encoding/json does.
+func addFields(t reflect.Type, props schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(field.Type, props, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		prop := schemaFor(field.Type)
+		if desc := field.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
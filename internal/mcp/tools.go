@@ -0,0 +1,393 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+	"github.com/odysseus0/feed/internal/timerange"
+)
+
+// defaultLimit matches the --limit default of `feed get entries` and
+// `feed search`.
+const defaultLimit = 50
+
+// tool is an MCP tool definition plus the function that runs it.
+type tool struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	InputSchema  schema          `json:"inputSchema"`
+	OutputSchema schema          `json:"outputSchema"`
+	Annotations  toolAnnotations `json:"annotations"`
+	call         func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+type toolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callToolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent any           `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+// newTool binds a typed handler to a tool whose input and output schemas are
+// derived from A and R.
+func newTool[A, R any](name, title, description string, annotations toolAnnotations, call func(context.Context, A) (R, error)) tool {
+	return tool{
+		Name:         name,
+		Title:        title,
+		Description:  description,
+		InputSchema:  schemaFor(reflect.TypeFor[A]()),
+		OutputSchema: schemaFor(reflect.TypeFor[R]()),
+		Annotations:  annotations,
+		call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			var args A
+			if len(raw) > 0 && string(raw) != "null" {
+				dec := json.NewDecoder(bytes.NewReader(raw))
+				dec.DisallowUnknownFields()
+				if err := dec.Decode(&args); err != nil {
+					return nil, fmt.Errorf("%w: arguments: %v", store.ErrInvalidInput, err)
+				}
+			}
+			return call(ctx, args)
+		},
+	}
+}
+
+// run calls the tool and wraps the outcome as a tool result. Tool failures
+// are reported in the result rather than as JSON-RPC errors so the model can
+// see them and correct its arguments.
+func (t tool) run(ctx context.Context, args json.RawMessage) callToolResult {
+	out, err := t.call(ctx, args)
+	if err != nil {
+		return callToolResult{Content: []textContent{{Type: "text", Text: formatError(err)}}, IsError: true}
+	}
+	text, err := json.Marshal(out)
+	if err != nil {
+		return callToolResult{Content: []textContent{{Type: "text", Text: formatError(err)}}, IsError: true}
+	}
+	return callToolResult{Content: []textContent{{Type: "text", Text: string(text)}}, StructuredContent: out}
+}
+
+// formatError labels errors with the codes the CLI and HTTP API use.
+func formatError(err error) string {
+	code := "internal"
+	switch {
+	case errors.Is(err, store.ErrInvalidInput):
+		code = "invalid-input"
+	case errors.Is(err, store.ErrNotFound):
+		code = "not-found"
+	case errors.Is(err, store.ErrConflict):
+		code = "conflict"
+	}
+	return fmt.Sprintf("Error [%s]: %v", code, err)
+}
+
+type listEntriesArgs struct {
+	Status  string   `json:"status,omitempty" desc:"unread (default), read, starred or all; combine with +, e.g. unread+starred"`
+	FeedID  int64    `json:"feed_id,omitempty" desc:"Only entries from this feed"`
+	Folder  string   `json:"folder,omitempty" desc:"Only entries from feeds in this folder"`
+	Tags    []string `json:"tags,omitempty" desc:"Only entries with all of these tags"`
+	Since   string   `json:"since,omitempty" desc:"Only entries published at or after this RFC3339 time, local YYYY-MM-DD date, or duration ago such as 2d"`
+	Until   string   `json:"until,omitempty" desc:"Only entries published at or before this RFC3339 time, local YYYY-MM-DD date (through the end of that day), or duration ago"`
+	After   string   `json:"after,omitempty" desc:"Resume after this cursor, the next_cursor of a previous page"`
+	Limit   int      `json:"limit,omitempty" desc:"Maximum entries to return (default 50)"`
+	NoFetch bool     `json:"no_fetch,omitempty" desc:"Skip fetching feeds when they are stale"`
+}
+
+type searchArgs struct {
+	Query  string   `json:"query" desc:"Full-text search query"`
+	FeedID int64    `json:"feed_id,omitempty" desc:"Only entries from this feed"`
+	Tags   []string `json:"tags,omitempty" desc:"Only entries with all of these tags"`
+	Since  string   `json:"since,omitempty" desc:"Only entries published at or after this RFC3339 time, local YYYY-MM-DD date, or duration ago such as 2d"`
+	Until  string   `json:"until,omitempty" desc:"Only entries published at or before this RFC3339 time, local YYYY-MM-DD date (through the end of that day), or duration ago"`
+	After  string   `json:"after,omitempty" desc:"Resume after this cursor, the next_cursor of a previous page"`
+	Limit  int      `json:"limit,omitempty" desc:"Maximum entries to return (default 50)"`
+}
+
+type getEntryArgs struct {
+	ID int64 `json:"id" desc:"Entry ID"`
+}
+
+type listFeedsArgs struct {
+	Folder string `json:"folder,omitempty" desc:"Only feeds in this folder"`
+}
+
+type getStatsArgs struct{}
+
+type updateEntriesArgs struct {
+	IDs     []int64 `json:"ids" desc:"Entry IDs to update"`
+	Read    *bool   `json:"read,omitempty" desc:"Mark the entries read (true) or unread (false)"`
+	Starred *bool   `json:"starred,omitempty" desc:"Star (true) or unstar (false) the entries"`
+}
+
+type addFeedArgs struct {
+	URL    string `json:"url" desc:"Feed URL, or a site URL to discover the feed from"`
+	Folder string `json:"folder,omitempty" desc:"Place the feed in this folder"`
+}
+
+type fetchArgs struct {
+	FeedID int64  `json:"feed_id,omitempty" desc:"Fetch only this feed"`
+	Folder string `json:"folder,omitempty" desc:"Fetch only feeds in this folder"`
+	Force  bool   `json:"force,omitempty" desc:"Fetch feeds even if they are not due yet"`
+}
+
+// entrySummary is an entry without its content, as listed by list_entries
+// and search_entries.
+type entrySummary struct {
+	ID          int64      `json:"id"`
+	FeedID      int64      `json:"feed_id"`
+	FeedTitle   string     `json:"feed_title"`
+	Title       string     `json:"title,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Read        bool       `json:"read"`
+	Starred     bool       `json:"starred"`
+	Tags        []string   `json:"tags,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+}
+
+// entryContent is an entry with its Markdown content: the extracted full
+// article when stored, else the feed content, else the summary.
+type entryContent struct {
+	entrySummary
+	Content    string      `json:"content"`
+	Enclosures []Enclosure `json:"enclosures,omitempty"`
+}
+
+type entriesResult struct {
+	Entries    []entrySummary `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty" desc:"Pass as after to get the next page; absent on the last page"`
+}
+
+type feedsResult struct {
+	Feeds []Feed `json:"feeds"`
+}
+
+func (s *Server) toolList() []tool {
+	read := toolAnnotations{ReadOnlyHint: true}
+	return []tool{
+		newTool("list_entries", "List entries",
+			"List entries, newest first (unread by default). Feeds are fetched first when stale unless no_fetch is set.",
+			toolAnnotations{OpenWorldHint: true}, s.listEntries),
+		newTool("get_entry", "Get entry", "Get one entry with its content as Markdown.", read, s.getEntry),
+		newTool("search_entries", "Search entries", "Full-text search over stored entries, best match first.", read, s.searchEntries),
+		newTool("list_feeds", "List feeds", "List subscribed feeds with unread and total counts.", read, s.listFeeds),
+		newTool("get_stats", "Get stats", "Count feeds and unread, starred and total entries.", read, s.getStats),
+		newTool("update_entries", "Update entries", "Mark entries read or unread, and star or unstar them.",
+			toolAnnotations{}, s.updateEntries),
+		newTool("add_feed", "Add feed", "Subscribe to a feed, discovering it from a site URL if needed, and fetch it.",
+			toolAnnotations{OpenWorldHint: true}, s.addFeed),
+		newTool("fetch_feeds", "Fetch feeds", "Fetch feeds that are due, or every matching feed with force.",
+			toolAnnotations{OpenWorldHint: true}, s.fetchFeeds),
+	}
+}
+
+func (s *Server) listEntries(ctx context.Context, args listEntriesArgs) (entriesResult, error) {
+	opts := EntryListOptions{
+		Status: args.Status,
+		FeedID: args.FeedID,
+		Folder: args.Folder,
+		Tags:   args.Tags,
+		After:  args.After,
+	}
+	if opts.Status == "" {
+		opts.Status = "unread"
+	}
+	var err error
+	if opts.Since, opts.Until, err = parseTimeRange(args.Since, args.Until); err != nil {
+		return entriesResult{}, err
+	}
+	if opts.Limit, err = checkLimit(args.Limit); err != nil {
+		return entriesResult{}, err
+	}
+	if !args.NoFetch {
+		hasFeeds, stale, _, err := s.store.GetFetchStaleness(ctx, s.cfg.StaleAfter)
+		if err != nil {
+			return entriesResult{}, fmt.Errorf("check fetch staleness: %w", err)
+		}
+		if hasFeeds && stale {
+			if _, err := s.fetcher.Fetch(ctx, nil); err != nil {
+				return entriesResult{}, fmt.Errorf("fetch feeds: %w", err)
+			}
+		}
+	}
+	entries, err := s.store.ListEntries(ctx, opts)
+	if err != nil {
+		return entriesResult{}, fmt.Errorf("list entries: %w", err)
+	}
+	return newEntriesResult(entries, opts.Limit), nil
+}
+
+func (s *Server) getEntry(ctx context.Context, args getEntryArgs) (entryContent, error) {
+	if args.ID <= 0 {
+		return entryContent{}, fmt.Errorf("%w: invalid id %d", store.ErrInvalidInput, args.ID)
+	}
+	entry, err := s.store.GetEntry(ctx, args.ID)
+	if err != nil {
+		return entryContent{}, fmt.Errorf("get entry %d: %w", args.ID, err)
+	}
+	content := strings.TrimSpace(entry.FullContentMD)
+	if content == "" {
+		content = strings.TrimSpace(entry.ContentMD)
+	}
+	if content == "" {
+		content = strings.TrimSpace(entry.Summary)
+	}
+	return entryContent{entrySummary: newEntrySummary(entry), Content: content, Enclosures: entry.Enclosures}, nil
+}
+
+func (s *Server) searchEntries(ctx context.Context, args searchArgs) (entriesResult, error) {
+	if strings.TrimSpace(args.Query) == "" {
+		return entriesResult{}, fmt.Errorf("%w: query is required", store.ErrInvalidInput)
+	}
+	opts := SearchOptions{Query: args.Query, Feed: args.FeedID, Tags: args.Tags, After: args.After}
+	var err error
+	if opts.Since, opts.Until, err = parseTimeRange(args.Since, args.Until); err != nil {
+		return entriesResult{}, err
+	}
+	if opts.Limit, err = checkLimit(args.Limit); err != nil {
+		return entriesResult{}, err
+	}
+	entries, err := s.store.SearchEntries(ctx, opts)
+	if err != nil {
+		return entriesResult{}, fmt.Errorf("search entries: %w", err)
+	}
+	return newEntriesResult(entries, opts.Limit), nil
+}
+
+func (s *Server) listFeeds(ctx context.Context, args listFeedsArgs) (feedsResult, error) {
+	feeds, err := s.store.ListFeedsWithCounts(ctx, FeedListOptions{Folder: args.Folder})
+	if err != nil {
+		return feedsResult{}, fmt.Errorf("list feeds: %w", err)
+	}
+	if feeds == nil {
+		feeds = []Feed{}
+	}
+	return feedsResult{Feeds: feeds}, nil
+}
+
+func (s *Server) getStats(ctx context.Context, _ getStatsArgs) (Stats, error) {
+	stats, err := s.store.GetStats(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("get stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *Server) updateEntries(ctx context.Context, args updateEntriesArgs) (BatchUpdateEntriesResponse, error) {
+	if err := s.store.UpdateEntries(ctx, UpdateEntriesInput{IDs: args.IDs, Read: args.Read, Starred: args.Starred}); err != nil {
+		return BatchUpdateEntriesResponse{}, fmt.Errorf("update entries: %w", err)
+	}
+	return BatchUpdateEntriesResponse{
+		Updated: len(args.IDs),
+		IDs:     args.IDs,
+		Read:    args.Read,
+		Starred: args.Starred,
+	}, nil
+}
+
+func (s *Server) addFeed(ctx context.Context, args addFeedArgs) (AddFeedResponse, error) {
+	added, err := s.fetcher.AddFeed(ctx, args.URL, args.Folder)
+	if err != nil {
+		return AddFeedResponse{}, err
+	}
+	added.FetchReport = withResults(added.FetchReport)
+	return added, nil
+}
+
+func (s *Server) fetchFeeds(ctx context.Context, args fetchArgs) (FetchReport, error) {
+	if args.FeedID < 0 {
+		return FetchReport{}, fmt.Errorf("%w: invalid feed_id %d", store.ErrInvalidInput, args.FeedID)
+	}
+	opts := FetchOptions{Folder: args.Folder, Force: args.Force}
+	if args.FeedID > 0 {
+		opts.FeedID = &args.FeedID
+	}
+	report, err := s.fetcher.FetchWithOptions(ctx, opts, nil)
+	if err != nil {
+		return FetchReport{}, fmt.Errorf("fetch feeds: %w", err)
+	}
+	return withResults(report), nil
+}
+
+func newEntrySummary(e Entry) entrySummary {
+	return entrySummary{
+		ID:          e.ID,
+		FeedID:      e.FeedID,
+		FeedTitle:   e.FeedTitle,
+		Title:       e.Title,
+		URL:         e.URL,
+		Author:      e.Author,
+		PublishedAt: e.PublishedAt,
+		Read:        e.Read,
+		Starred:     e.Starred,
+		Tags:        e.Tags,
+		Summary:     e.Summary,
+	}
+}
+
+// newEntriesResult summarizes a page of entries. A full page carries the
+// last entry's cursor, as `feed get entries` prints it.
+func newEntriesResult(entries []Entry, limit int) entriesResult {
+	out := entriesResult{Entries: make([]entrySummary, 0, len(entries))}
+	for _, e := range entries {
+		out.Entries = append(out.Entries, newEntrySummary(e))
+	}
+	if len(entries) > 0 && len(entries) == limit {
+		out.NextCursor = entries[len(entries)-1].Cursor
+	}
+	return out
+}
+
+// withResults keeps results an array in structured output, as the output
+// schema requires.
+func withResults(report FetchReport) FetchReport {
+	if report.Results == nil {
+		report.Results = []FetchResult{}
+	}
+	return report
+}
+
+func checkLimit(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: invalid limit %d", store.ErrInvalidInput, limit)
+	case limit == 0:
+		return defaultLimit, nil
+	default:
+		return limit, nil
+	}
+}
+
+// parseTimeRange reads the optional since and until bounds, which take the
+// same values as the CLI's --since and --until.
+func parseTimeRange(since, until string) (*time.Time, *time.Time, error) {
+	now := time.Now()
+	sinceT, err := timerange.Since(since, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: since: %v", store.ErrInvalidInput, err)
+	}
+	untilT, err := timerange.Until(until, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: until: %v", store.ErrInvalidInput, err)
+	}
+	return sinceT, untilT, nil
+}
@@ -11,19 +11,51 @@ const (
 )
 
 type Feed struct {
-	ID            int64      `json:"id"`
-	URL           string     `json:"url"`
-	SiteURL       string     `json:"site_url,omitempty"`
-	Title         string     `json:"title,omitempty"`
-	Description   string     `json:"description,omitempty"`
-	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
-	ETag          string     `json:"etag,omitempty"`
-	LastModified  string     `json:"last_modified,omitempty"`
-	LastError     string     `json:"last_error,omitempty"`
-	ErrorCount    int        `json:"error_count"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UnreadCount   int        `json:"unread_count"`
-	TotalCount    int        `json:"total_count"`
+	ID                   int64      `json:"id"`
+	URL                  string     `json:"url"`
+	SiteURL              string     `json:"site_url,omitempty"`
+	Title                string     `json:"title,omitempty"`
+	CustomTitle          string     `json:"custom_title,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	LastFetchedAt        *time.Time `json:"last_fetched_at,omitempty"`
+	ETag                 string     `json:"etag,omitempty"`
+	LastModified         string     `json:"last_modified,omitempty"`
+	LastError            string     `json:"last_error,omitempty"`
+	ErrorCount           int        `json:"error_count"`
+	FolderID             int64      `json:"folder_id,omitempty"`
+	Folder               string     `json:"folder,omitempty"`
+	Paused               bool       `json:"paused"`
+	FetchIntervalMinutes int        `json:"fetch_interval_minutes,omitempty"`
+	FullContent          bool       `json:"full_content"`
+	AutoDownload         bool       `json:"auto_download"`
+	NextFetchAt          *time.Time `json:"next_fetch_at,omitempty"`
+	RetryAfter           *time.Time `json:"retry_after,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UnreadCount          int        `json:"unread_count"`
+	TotalCount           int        `json:"total_count"`
+}
+
+// UpdateFeedInput carries per-feed settings; nil fields are left unchanged.
+type UpdateFeedInput struct {
+	URL                  *string
+	CustomTitle          *string
+	Paused               *bool
+	FetchIntervalMinutes *int
+	FullContent          *bool
+	AutoDownload         *bool
+}
+
+// UpdateEntriesInput changes the read and/or starred state of a set of
+// entries; nil fields are left alone.
+type UpdateEntriesInput struct {
+	IDs     []int64
+	Read    *bool
+	Starred *bool
+}
+
+type Folder struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
 }
 
 type Entry struct {
@@ -43,6 +75,101 @@ type Entry struct {
 	FetchedAt    time.Time  `json:"fetched_at"`
 	Read         bool       `json:"read"`
 	Starred      bool       `json:"starred"`
+	Tags         []string   `json:"tags,omitempty"`
+	// Full* hold the article extracted from the entry's web page, kept
+	// alongside the feed-provided content.
+	FullContentHTML      string      `json:"full_content_html,omitempty"`
+	FullContentMD        string      `json:"full_content_md,omitempty"`
+	FullContentFetchedAt *time.Time  `json:"full_content_fetched_at,omitempty"`
+	Enclosures           []Enclosure `json:"enclosures,omitempty"`
+	Cursor               string      `json:"cursor,omitempty"`
+	// Truncated is set when an output budget cut the entry's text.
+	Truncated *Truncation `json:"truncated,omitempty"`
+}
+
+// Truncation reports which part of an entry's text an output budget kept:
+// ShownChars characters starting at Offset, out of TotalChars. MaxChars is the
+// entry's share of the budget, to pass as --max-chars when reading on.
+type Truncation struct {
+	Field      string `json:"field"`
+	TotalChars int    `json:"total_chars"`
+	Offset     int    `json:"offset"`
+	ShownChars int    `json:"shown_chars"`
+	MaxChars   int    `json:"max_chars,omitempty"`
+}
+
+// Enclosure is a media file attached to an entry, such as a podcast episode
+// or video. Length is in bytes.
+type Enclosure struct {
+	URL             string `json:"url"`
+	MimeType        string `json:"mime_type,omitempty"`
+	Length          int64  `json:"length,omitempty"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+}
+
+// EntryEnclosure is an enclosure listed together with the entry it belongs to.
+type EntryEnclosure struct {
+	EntryID     int64      `json:"entry_id"`
+	FeedID      int64      `json:"feed_id"`
+	FeedTitle   string     `json:"feed_title"`
+	EntryTitle  string     `json:"entry_title,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Enclosure
+}
+
+// MediaFile is a local copy of an entry's enclosure or inline image. Path is
+// relative to the media directory.
+type MediaFile struct {
+	ID           int64     `json:"id"`
+	EntryID      int64     `json:"entry_id"`
+	URL          string    `json:"url"`
+	Path         string    `json:"path"`
+	Kind         string    `json:"kind"`
+	MimeType     string    `json:"mime_type,omitempty"`
+	Size         int64     `json:"size"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	AccessedAt   time.Time `json:"accessed_at"`
+}
+
+// MediaDownloadResult summarizes downloading one entry's media. Cached counts
+// files that were already present locally.
+type MediaDownloadResult struct {
+	EntryID    int64    `json:"entry_id"`
+	Downloaded int      `json:"downloaded"`
+	Cached     int      `json:"cached"`
+	Bytes      int64    `json:"bytes"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// EntryRevision is one version of an entry's content. Revisions are numbered
+// from 1 (oldest) and the last one is the entry's current content.
+type EntryRevision struct {
+	Revision    int       `json:"revision"`
+	EntryID     int64     `json:"entry_id"`
+	Title       string    `json:"title,omitempty"`
+	ContentHTML string    `json:"content_html,omitempty"`
+	ContentMD   string    `json:"content_md,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Current     bool      `json:"current"`
+}
+
+// AddFeedResponse reports a feed subscription and its initial fetch.
+type AddFeedResponse struct {
+	Feed          Feed        `json:"feed"`
+	Inserted      bool        `json:"inserted"`
+	DiscoveredURL string      `json:"discovered_url"`
+	FetchReport   FetchReport `json:"fetch_report"`
+}
+
+// BatchUpdateEntriesResponse reports a status or tag change applied to a set
+// of entries.
+type BatchUpdateEntriesResponse struct {
+	Updated     int      `json:"updated"`
+	IDs         []int64  `json:"ids"`
+	Read        *bool    `json:"read,omitempty"`
+	Starred     *bool    `json:"starred,omitempty"`
+	TagsAdded   []string `json:"tags_added,omitempty"`
+	TagsRemoved []string `json:"tags_removed,omitempty"`
 }
 
 type Stats struct {
@@ -59,6 +186,8 @@ type FetchResult struct {
 	NewEntries  int    `json:"new_entries"`
 	Updated     int    `json:"updated_entries"`
 	NotModified bool   `json:"not_modified"`
+	MovedTo     string `json:"moved_to,omitempty"`
+	Warning     string `json:"warning,omitempty"`
 	Error       string `json:"error,omitempty"`
 }
 
@@ -72,12 +201,67 @@ type FetchReport struct {
 type EntryListOptions struct {
 	Status string
 	FeedID int64
+	Folder string
+	Tags   []string
+	Since  *time.Time
+	Until  *time.Time
+	After  string
 	Limit  int
 }
 
+type EnclosureListOptions struct {
+	MimeType string
+	FeedID   int64
+	Limit    int
+}
+
+// EntryIDListOptions selects entries for sync APIs that page by entry ID
+// rather than cursors. SinceID and MaxID are exclusive bounds; Status,
+// FeedID, Folder, Since and Until filter as in EntryListOptions, except that
+// an empty Status matches every entry. Entries are ordered by ID, newest
+// first when Descending is set.
+type EntryIDListOptions struct {
+	SinceID    int64
+	MaxID      int64
+	IDs        []int64
+	Status     string
+	FeedID     int64
+	Folder     string
+	Since      *time.Time
+	Until      *time.Time
+	Descending bool
+	Limit      int
+}
+
+// MarkReadOptions scopes a mark-all-as-read to a feed or a folder; with
+// neither set it covers every feed. A nil Before marks all entries.
+type MarkReadOptions struct {
+	FeedID   int64
+	FolderID int64
+	Before   *time.Time
+}
+
+type FeedListOptions struct {
+	Folder string
+}
+
+type FetchOptions struct {
+	FeedID *int64
+	Force  bool
+	Folder string
+	// DownloadMedia downloads media for feeds with auto-download enabled
+	// once fetching is done. Only `feed fetch` sets it, so implicit and API
+	// fetches never wait on large files.
+	DownloadMedia bool
+}
+
 type SearchOptions struct {
 	Query string
 	Feed  int64
+	Tags  []string
+	Since *time.Time
+	Until *time.Time
+	After string
 	Limit int
 }
 
@@ -93,4 +277,9 @@ type UpsertEntryInput struct {
 	Author       string
 	PublishedAt  *time.Time
 	DateModified *time.Time
+	Enclosures   []Enclosure
+	// SourceHash fingerprints the item as the feed published it, before
+	// sanitizing and rendering. When it matches the stored one, a changed
+	// entry only reflects a change in that processing.
+	SourceHash string
 }
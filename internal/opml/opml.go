@@ -38,7 +38,26 @@ type opmlOutline struct {
 	Outlines     []opmlOutline `xml:"outline,omitempty"`
 }
 
+// Subscription is a feed URL read from an OPML document, along with the
+// folder named by its nearest parent outline (empty at the top level).
+type Subscription struct {
+	URL    string
+	Folder string
+}
+
 func ReadOPML(path string) ([]string, error) {
+	subs, err := ReadOPMLSubscriptions(path)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		urls = append(urls, sub.URL)
+	}
+	return urls, nil
+}
+
+func ReadOPMLSubscriptions(path string) ([]Subscription, error) {
 	r, err := openOPML(path)
 	if err != nil {
 		return nil, err
@@ -54,21 +73,21 @@ func ReadOPML(path string) ([]string, error) {
 		return nil, err
 	}
 
-	var urls []string
-	var walk func([]opmlOutline)
-	walk = func(outlines []opmlOutline) {
+	var subs []Subscription
+	var walk func(outlines []opmlOutline, folder string)
+	walk = func(outlines []opmlOutline, folder string) {
 		for _, o := range outlines {
 			if feedURL := o.FeedURL(); feedURL != "" {
-				urls = append(urls, feedURL)
+				subs = append(subs, Subscription{URL: feedURL, Folder: folder})
 			}
 			if len(o.Outlines) > 0 {
-				walk(o.Outlines)
+				walk(o.Outlines, fallback(o.Name(), folder))
 			}
 		}
 	}
-	walk(doc.Body.Outlines)
+	walk(doc.Body.Outlines, "")
 
-	return uniqueStrings(urls), nil
+	return uniqueSubscriptions(subs), nil
 }
 
 func openOPML(path string) (io.ReadCloser, error) {
@@ -88,14 +107,27 @@ func openOPML(path string) (io.ReadCloser, error) {
 
 func WriteOPML(w io.Writer, feeds []model.Feed) error {
 	outlines := make([]opmlOutline, 0, len(feeds))
+	folderIdx := make(map[string]int)
 	for _, f := range feeds {
-		outlines = append(outlines, opmlOutline{
+		outline := opmlOutline{
 			Text:    fallback(strings.TrimSpace(f.Title), f.URL),
 			Title:   fallback(strings.TrimSpace(f.Title), f.URL),
 			Type:    "rss",
 			XMLURL:  f.URL,
 			HTMLURL: f.SiteURL,
-		})
+		}
+		folder := strings.TrimSpace(f.Folder)
+		if folder == "" {
+			outlines = append(outlines, outline)
+			continue
+		}
+		idx, ok := folderIdx[strings.ToLower(folder)]
+		if !ok {
+			idx = len(outlines)
+			folderIdx[strings.ToLower(folder)] = idx
+			outlines = append(outlines, opmlOutline{Text: folder, Title: folder})
+		}
+		outlines[idx].Outlines = append(outlines[idx].Outlines, outline)
 	}
 
 	doc := opmlDoc{
@@ -104,11 +136,7 @@ func WriteOPML(w io.Writer, feeds []model.Feed) error {
 			Title: "feed export",
 		},
 		Body: opmlBody{
-			Outlines: []opmlOutline{{
-				Text:     "Subscriptions",
-				Title:    "Subscriptions",
-				Outlines: outlines,
-			}},
+			Outlines: outlines,
 		},
 	}
 
@@ -130,14 +158,14 @@ func fallback(v, fb string) string {
 	return v
 }
 
-func uniqueStrings(in []string) []string {
+func uniqueSubscriptions(in []Subscription) []Subscription {
 	seen := make(map[string]struct{}, len(in))
-	out := make([]string, 0, len(in))
+	out := make([]Subscription, 0, len(in))
 	for _, v := range in {
-		if _, ok := seen[v]; ok {
+		if _, ok := seen[v.URL]; ok {
 			continue
 		}
-		seen[v] = struct{}{}
+		seen[v.URL] = struct{}{}
 		out = append(out, v)
 	}
 	return out
@@ -152,3 +180,10 @@ func (o opmlOutline) FeedURL() string {
 	}
 	return ""
 }
+
+func (o opmlOutline) Name() string {
+	if v := strings.TrimSpace(o.Text); v != "" {
+		return v
+	}
+	return strings.TrimSpace(o.Title)
+}
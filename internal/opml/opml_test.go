@@ -50,3 +50,74 @@ func TestWriteOPML_ContainsFeedURLs(t *testing.T) {
 		}
 	}
 }
+
+func TestReadOPMLSubscriptions_MapsParentOutlineToFolder(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "in.opml")
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <body>
+    <outline text="Loose" xmlUrl="https://loose.example/feed.xml" />
+    <outline title="Tech">
+      <outline text="A" xmlUrl="https://a.example/feed.xml" />
+    </outline>
+    <outline text="News">
+      <outline text="B" xmlUrl="https://b.example/feed.xml" />
+    </outline>
+  </body>
+</opml>`
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		t.Fatalf("write opml: %v", err)
+	}
+
+	subs, err := ReadOPMLSubscriptions(tmp)
+	if err != nil {
+		t.Fatalf("read opml: %v", err)
+	}
+	want := []Subscription{
+		{URL: "https://loose.example/feed.xml"},
+		{URL: "https://a.example/feed.xml", Folder: "Tech"},
+		{URL: "https://b.example/feed.xml", Folder: "News"},
+	}
+	if len(subs) != len(want) {
+		t.Fatalf("expected %d subscriptions, got %#v", len(want), subs)
+	}
+	for i := range want {
+		if subs[i] != want[i] {
+			t.Fatalf("subscription %d = %#v, want %#v", i, subs[i], want[i])
+		}
+	}
+}
+
+func TestWriteOPML_RoundTripsFolders(t *testing.T) {
+	var b strings.Builder
+	feeds := []model.Feed{
+		{Title: "A", URL: "https://a.example/feed.xml", Folder: "Tech"},
+		{Title: "B", URL: "https://b.example/feed.xml"},
+		{Title: "C", URL: "https://c.example/feed.xml", Folder: "Tech"},
+	}
+	if err := WriteOPML(&b, feeds); err != nil {
+		t.Fatalf("write opml: %v", err)
+	}
+	if strings.Contains(b.String(), "Subscriptions") {
+		t.Fatalf("unexpected wrapper outline in output: %s", b.String())
+	}
+
+	tmp := filepath.Join(t.TempDir(), "out.opml")
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("write exported opml: %v", err)
+	}
+	subs, err := ReadOPMLSubscriptions(tmp)
+	if err != nil {
+		t.Fatalf("read exported opml: %v", err)
+	}
+	folders := map[string]string{}
+	for _, sub := range subs {
+		folders[sub.URL] = sub.Folder
+	}
+	if folders["https://a.example/feed.xml"] != "Tech" || folders["https://c.example/feed.xml"] != "Tech" {
+		t.Fatalf("expected Tech folder to round-trip, got %#v", folders)
+	}
+	if folders["https://b.example/feed.xml"] != "" {
+		t.Fatalf("expected unfiled feed at top level, got %#v", folders)
+	}
+}
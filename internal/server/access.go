@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	errForbidden    = errors.New("forbidden")
+	errUnauthorized = errors.New("unauthorized")
+)
+
+// Options controls which requests the server accepts.
+type Options struct {
+	// Addr is the address the server listens on. Requests must name it,
+	// localhost or a loopback IP in their Host header; when Addr's host is
+	// unspecified (":7070", "0.0.0.0:7070"), any IP literal is accepted too.
+	Addr string
+	// AllowedHosts are further host names to accept, such as the machine's
+	// LAN name.
+	AllowedHosts []string
+	// NoJSONAPI leaves out the /v1 routes, for serving only the sync APIs.
+	NoJSONAPI bool
+}
+
+// LoopbackAddr reports whether addr only listens on the local machine.
+func LoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkAccess rejects requests for foreign host names, which is how DNS
+// rebinding reaches a local server, and requests a browser sent on behalf of
+// another site.
+func (s *Server) checkAccess(r *http.Request) error {
+	if !s.allowedHost(r.Host) {
+		return fmt.Errorf("%w: host %q is not allowed", errForbidden, r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !s.allowedHost(u.Host) {
+			return fmt.Errorf("%w: cross-origin request from %q", errForbidden, origin)
+		}
+	}
+	return nil
+}
+
+func (s *Server) allowedHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+	bound, _, _ := net.SplitHostPort(s.opts.Addr)
+	bound = strings.Trim(bound, "[]")
+	if bound != "" && strings.EqualFold(host, bound) {
+		return true
+	}
+	if ip != nil && (bound == "" || net.ParseIP(bound).IsUnspecified()) {
+		return true
+	}
+	return slices.ContainsFunc(s.opts.AllowedHosts, func(h string) bool { return strings.EqualFold(h, host) })
+}
+
+// requireToken guards the /v1 JSON API with the configured bearer token.
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	if s.cfg.APIToken == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.cfg.APIToken)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="feed"`)
+			writeError(w, errUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/fetch"
+	"github.com/odysseus0/feed/internal/model"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+type Config = config.Config
+type Store = store.Store
+type Fetcher = fetch.Fetcher
+type Entry = model.Entry
+type Feed = model.Feed
+type EntryListOptions = model.EntryListOptions
+type EntryIDListOptions = model.EntryIDListOptions
+type MarkReadOptions = model.MarkReadOptions
+type FeedListOptions = model.FeedListOptions
+type FetchOptions = model.FetchOptions
+type SearchOptions = model.SearchOptions
+type UpdateFeedInput = model.UpdateFeedInput
+type BatchUpdateEntriesResponse = model.BatchUpdateEntriesResponse
+type UpdateEntriesInput = model.UpdateEntriesInput
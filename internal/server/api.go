@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// updateEntriesRequest is the body of PATCH /v1/entries. At least one of
+// Read and Starred must be set.
+type updateEntriesRequest struct {
+	IDs     []int64 `json:"ids"`
+	Read    *bool   `json:"read"`
+	Starred *bool   `json:"starred"`
+}
+
+// fetchRequest is the optional body of POST /v1/fetch. Without a feed ID only
+// feeds that are due are fetched, unless Force is set.
+type fetchRequest struct {
+	FeedID int64  `json:"feed_id"`
+	Folder string `json:"folder"`
+	Force  bool   `json:"force"`
+}
+
+func (s *Server) handleListEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := EntryListOptions{
+		Status: q.Get("status"),
+		Folder: q.Get("folder"),
+		Tags:   queryTags(q),
+		After:  q.Get("after"),
+	}
+	if opts.Status == "" {
+		opts.Status = "unread"
+	}
+	var err error
+	if opts.FeedID, err = queryID(q, "feed"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if opts.Since, opts.Until, err = queryTimeRange(q); err != nil {
+		writeError(w, err)
+		return
+	}
+	if opts.Limit, err = queryLimit(q); err != nil {
+		writeError(w, err)
+		return
+	}
+	entries, err := s.store.ListEntries(r.Context(), opts)
+	if err != nil {
+		writeError(w, fmt.Errorf("list entries: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleGetEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entry, err := s.store.GetEntry(r.Context(), id)
+	if err != nil {
+		writeError(w, fmt.Errorf("get entry %d: %w", id, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := SearchOptions{
+		Query: q.Get("q"),
+		Tags:  queryTags(q),
+		After: q.Get("after"),
+	}
+	if opts.Query == "" {
+		writeError(w, fmt.Errorf("%w: missing search query (q)", store.ErrInvalidInput))
+		return
+	}
+	var err error
+	if opts.Feed, err = queryID(q, "feed"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if opts.Since, opts.Until, err = queryTimeRange(q); err != nil {
+		writeError(w, err)
+		return
+	}
+	if opts.Limit, err = queryLimit(q); err != nil {
+		writeError(w, err)
+		return
+	}
+	entries, err := s.store.SearchEntries(r.Context(), opts)
+	if err != nil {
+		writeError(w, fmt.Errorf("search entries: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	feeds, err := s.store.ListFeedsWithCounts(r.Context(), FeedListOptions{Folder: r.URL.Query().Get("folder")})
+	if err != nil {
+		writeError(w, fmt.Errorf("list feeds: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, feeds)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.store.GetStats(r.Context())
+	if err != nil {
+		writeError(w, fmt.Errorf("get stats: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleUpdateEntries(w http.ResponseWriter, r *http.Request) {
+	var req updateEntriesRequest
+	if err := decodeBody(w, r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.store.UpdateEntries(r.Context(), UpdateEntriesInput{IDs: req.IDs, Read: req.Read, Starred: req.Starred}); err != nil {
+		writeError(w, fmt.Errorf("update entries: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, BatchUpdateEntriesResponse{
+		Updated: len(req.IDs),
+		IDs:     req.IDs,
+		Read:    req.Read,
+		Starred: req.Starred,
+	})
+}
+
+func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
+	var req fetchRequest
+	if err := decodeBody(w, r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.FeedID < 0 {
+		writeError(w, fmt.Errorf("%w: invalid feed_id %d", store.ErrInvalidInput, req.FeedID))
+		return
+	}
+	opts := FetchOptions{Folder: req.Folder, Force: req.Force}
+	if req.FeedID > 0 {
+		opts.FeedID = &req.FeedID
+	}
+	rep, err := s.fetcher.FetchWithOptions(r.Context(), opts, nil)
+	if err != nil {
+		writeError(w, fmt.Errorf("fetch feeds: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// feverAPIVersion is the Fever API version reported to clients.
+const feverAPIVersion = 3
+
+// feverMaxItems is the most items Fever returns per request, including for
+// with_ids.
+const feverMaxItems = 50
+
+// handleFever implements the Fever API (https://feedafever.com/api) used by
+// mobile readers such as Reeder and Unread. Folders are Fever groups, and
+// starred entries are saved items. Requests are authenticated with the
+// api_key form value, and marks are applied before any data is returned.
+func (s *Server) handleFever(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", store.ErrInvalidInput, err))
+		return
+	}
+	if _, ok := r.Form["api"]; !ok {
+		writeError(w, fmt.Errorf("%w: missing api parameter", store.ErrInvalidInput))
+		return
+	}
+	resp := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+	if !s.feverAuthorized(r.Form.Get("api_key")) {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp["auth"] = 1
+
+	ctx := r.Context()
+	_, _, lastFetched, err := s.store.GetFetchStaleness(ctx, 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp["last_refreshed_on_time"] = unixOrZero(lastFetched)
+
+	if r.Form.Get("mark") != "" {
+		if err := s.feverMark(r, resp); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	has := func(key string) bool {
+		_, ok := r.Form[key]
+		return ok
+	}
+	if has("groups") || has("feeds") {
+		feeds, err := s.store.ListFeedsWithCounts(ctx, FeedListOptions{})
+		if err != nil {
+			writeError(w, fmt.Errorf("list feeds: %w", err))
+			return
+		}
+		if has("groups") {
+			folders, err := s.store.ListFolders(ctx)
+			if err != nil {
+				writeError(w, fmt.Errorf("list folders: %w", err))
+				return
+			}
+			groups := make([]map[string]any, 0, len(folders))
+			for _, folder := range folders {
+				groups = append(groups, map[string]any{"id": folder.ID, "title": folder.Name})
+			}
+			resp["groups"] = groups
+		}
+		if has("feeds") {
+			out := make([]map[string]any, 0, len(feeds))
+			for _, feed := range feeds {
+				out = append(out, map[string]any{
+					"id":                   feed.ID,
+					"favicon_id":           0,
+					"title":                fallback(feed.Title, feed.URL),
+					"url":                  feed.URL,
+					"site_url":             feed.SiteURL,
+					"is_spark":             0,
+					"last_updated_on_time": unixOrZero(feed.LastFetchedAt),
+				})
+			}
+			resp["feeds"] = out
+		}
+		resp["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+	if has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if has("links") {
+		resp["links"] = []any{}
+	}
+	if has("items") {
+		opts, err := feverItemOptions(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		entries, err := s.store.ListEntriesByID(ctx, opts)
+		if err != nil {
+			writeError(w, fmt.Errorf("list items: %w", err))
+			return
+		}
+		items := make([]map[string]any, 0, len(entries))
+		for _, e := range entries {
+			items = append(items, feverItem(e))
+		}
+		stats, err := s.store.GetStats(ctx)
+		if err != nil {
+			writeError(w, fmt.Errorf("get stats: %w", err))
+			return
+		}
+		resp["items"] = items
+		resp["total_items"] = stats.Total
+	}
+	if has("unread_item_ids") {
+		if err := s.feverItemIDs(r, resp, "unread_item_ids", "unread"); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if has("saved_item_ids") {
+		if err := s.feverItemIDs(r, resp, "saved_item_ids", "starred"); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) feverAuthorized(key string) bool {
+	want := strings.ToLower(s.cfg.FeverAPIKey)
+	got := strings.ToLower(strings.TrimSpace(key))
+	return want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// feverMark applies mark=item|feed|group. Items that no longer exist are
+// ignored, since clients may hold IDs of entries pruned since their last
+// sync. The updated unread or saved ID list is added to resp.
+func (s *Server) feverMark(r *http.Request, resp map[string]any) error {
+	ctx := r.Context()
+	mark, as := r.Form.Get("mark"), r.Form.Get("as")
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid id %q", store.ErrInvalidInput, r.Form.Get("id"))
+	}
+
+	switch mark {
+	case "item":
+		switch as {
+		case "read", "unread":
+			err = s.store.SetEntriesRead(ctx, []int64{id}, as == "read")
+		case "saved", "unsaved":
+			err = s.store.SetEntriesStarred(ctx, []int64{id}, as == "saved")
+		default:
+			return fmt.Errorf("%w: invalid as %q for items (expected read|unread|saved|unsaved)", store.ErrInvalidInput, as)
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("mark item %d %s: %w", id, as, err)
+		}
+		if as == "saved" || as == "unsaved" {
+			return s.feverItemIDs(r, resp, "saved_item_ids", "starred")
+		}
+		return s.feverItemIDs(r, resp, "unread_item_ids", "unread")
+	case "feed", "group":
+		if as != "read" {
+			return fmt.Errorf("%w: invalid as %q for %ss (expected read)", store.ErrInvalidInput, as, mark)
+		}
+		opts := MarkReadOptions{}
+		if before, err := strconv.ParseInt(r.Form.Get("before"), 10, 64); err == nil && before > 0 {
+			t := time.Unix(before, 0).UTC()
+			opts.Before = &t
+		}
+		switch {
+		case mark == "feed":
+			if id <= 0 {
+				return fmt.Errorf("%w: invalid feed id %d", store.ErrInvalidInput, id)
+			}
+			opts.FeedID = id
+		case id < 0:
+			// Group -1 holds Fever's "sparks", which are never populated here.
+			return s.feverItemIDs(r, resp, "unread_item_ids", "unread")
+		default:
+			// Group 0 is Fever's "Kindling", every feed.
+			opts.FolderID = id
+		}
+		if _, err := s.store.MarkEntriesRead(ctx, opts); err != nil {
+			return fmt.Errorf("mark %s %d read: %w", mark, id, err)
+		}
+		return s.feverItemIDs(r, resp, "unread_item_ids", "unread")
+	default:
+		return fmt.Errorf("%w: invalid mark %q (expected item|feed|group)", store.ErrInvalidInput, mark)
+	}
+}
+
+func (s *Server) feverItemIDs(r *http.Request, resp map[string]any, key, status string) error {
+	ids, err := s.store.ListEntryIDs(r.Context(), EntryIDListOptions{Status: status})
+	if err != nil {
+		return fmt.Errorf("list %s entry ids: %w", status, err)
+	}
+	resp[key] = joinIDs(ids)
+	return nil
+}
+
+// feverItemOptions reads since_id, max_id and with_ids. Items after since_id
+// come oldest first and items before max_id newest first; without either,
+// items start from the oldest, as Fever clients then page with since_id.
+func feverItemOptions(r *http.Request) (EntryIDListOptions, error) {
+	opts := EntryIDListOptions{Limit: feverMaxItems}
+	for _, key := range []string{"since_id", "max_id"} {
+		raw := r.Form.Get(key)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			return EntryIDListOptions{}, fmt.Errorf("%w: invalid %s %q", store.ErrInvalidInput, key, raw)
+		}
+		if key == "since_id" {
+			opts.SinceID = n
+		} else {
+			opts.MaxID = n
+			opts.Descending = true
+		}
+	}
+	if raw := r.Form.Get("with_ids"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			id, err := parseID(part)
+			if err != nil {
+				return EntryIDListOptions{}, fmt.Errorf("with_ids: %w", err)
+			}
+			opts.IDs = append(opts.IDs, id)
+		}
+		if len(opts.IDs) > feverMaxItems {
+			opts.IDs = opts.IDs[:feverMaxItems]
+		}
+	}
+	return opts, nil
+}
+
+func feverItem(e Entry) map[string]any {
+	html := e.FullContentHTML
+	if html == "" {
+		html = e.ContentHTML
+	}
+	if html == "" {
+		html = e.Summary
+	}
+	created := e.FetchedAt
+	if e.PublishedAt != nil {
+		created = *e.PublishedAt
+	}
+	return map[string]any{
+		"id":              e.ID,
+		"feed_id":         e.FeedID,
+		"title":           e.Title,
+		"author":          e.Author,
+		"html":            html,
+		"url":             fallback(e.URL, e.ExternalURL),
+		"is_saved":        boolInt(e.Starred),
+		"is_read":         boolInt(e.Read),
+		"created_on_time": created.Unix(),
+	}
+}
+
+// feverFeedsGroups lists the feed IDs in each folder, comma-separated as
+// Fever expects.
+func feverFeedsGroups(feeds []Feed) []map[string]any {
+	byFolder := make(map[int64][]int64)
+	order := make([]int64, 0)
+	for _, feed := range feeds {
+		if feed.FolderID == 0 {
+			continue
+		}
+		if _, ok := byFolder[feed.FolderID]; !ok {
+			order = append(order, feed.FolderID)
+		}
+		byFolder[feed.FolderID] = append(byFolder[feed.FolderID], feed.ID)
+	}
+	out := make([]map[string]any, 0, len(order))
+	for _, folderID := range order {
+		out = append(out, map[string]any{"group_id": folderID, "feed_ids": joinIDs(byFolder[folderID])})
+	}
+	return out
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func fallback(v, fb string) string {
+	if strings.TrimSpace(v) == "" {
+		return fb
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/fetch"
+	"github.com/odysseus0/feed/internal/model"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// testFeverKey is md5("me:secret").
+const testFeverKey = "5f67bbe865987f84db7ba3daea424dcf"
+
+type feverTestResponse struct {
+	Auth          int    `json:"auth"`
+	UnreadItemIDs string `json:"unread_item_ids"`
+	SavedItemIDs  string `json:"saved_item_ids"`
+	Groups        []struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	} `json:"groups"`
+	Feeds []struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	} `json:"feeds"`
+	FeedsGroups []struct {
+		GroupID int64  `json:"group_id"`
+		FeedIDs string `json:"feed_ids"`
+	} `json:"feeds_groups"`
+	Items []struct {
+		ID     int64  `json:"id"`
+		FeedID int64  `json:"feed_id"`
+		HTML   string `json:"html"`
+		IsRead int    `json:"is_read"`
+	} `json:"items"`
+	TotalItems int `json:"total_items"`
+}
+
+func feverPost(t *testing.T, apiURL, query string, form url.Values) feverTestResponse {
+	t.Helper()
+	resp, err := http.PostForm(apiURL+"/fever/?api"+query, form)
+	if err != nil {
+		t.Fatalf("fever request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("fever %s: status %d", query, resp.StatusCode)
+	}
+	var out feverTestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode fever response: %v", err)
+	}
+	return out
+}
+
+func TestFeverAPI(t *testing.T) {
+	api, s := newTestServer(t, config.Config{FeverAPIKey: testFeverKey})
+	ctx := context.Background()
+	feed, _, err := s.CreateFeed(ctx, "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	if err := s.SetFeedFolder(ctx, feed.ID, "Tech"); err != nil {
+		t.Fatalf("set folder: %v", err)
+	}
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if _, _, err := s.UpsertEntries(ctx, []model.UpsertEntryInput{
+		{FeedID: feed.ID, GUID: "a", Title: "A", ContentHTML: "<p>a</p>", PublishedAt: &jan},
+		{FeedID: feed.ID, GUID: "b", Title: "B", ContentHTML: "<p>b</p>", PublishedAt: &feb},
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	auth := url.Values{"api_key": {testFeverKey}}
+
+	if got := feverPost(t, api.URL, "&items", url.Values{"api_key": {"wrong"}}); got.Auth != 0 || got.Items != nil {
+		t.Fatalf("expected unauthenticated response, got %+v", got)
+	}
+
+	got := feverPost(t, api.URL, "&groups&feeds", auth)
+	if got.Auth != 1 || len(got.Groups) != 1 || got.Groups[0].Title != "Tech" || len(got.Feeds) != 1 {
+		t.Fatalf("unexpected groups/feeds: %+v", got)
+	}
+	if len(got.FeedsGroups) != 1 || got.FeedsGroups[0].FeedIDs != strconv.FormatInt(feed.ID, 10) {
+		t.Fatalf("unexpected feeds_groups: %+v", got.FeedsGroups)
+	}
+
+	got = feverPost(t, api.URL, "&items", auth)
+	if len(got.Items) != 2 || got.TotalItems != 2 || got.Items[0].HTML != "<p>a</p>" {
+		t.Fatalf("unexpected items: %+v", got)
+	}
+	first, second := got.Items[0].ID, got.Items[1].ID
+	got = feverPost(t, api.URL, "&items&since_id="+strconv.FormatInt(first, 10), auth)
+	if len(got.Items) != 1 || got.Items[0].ID != second {
+		t.Fatalf("unexpected since_id page: %+v", got.Items)
+	}
+
+	mark := url.Values{"api_key": {testFeverKey}, "mark": {"item"}, "as": {"saved"}, "id": {strconv.FormatInt(first, 10)}}
+	if got := feverPost(t, api.URL, "", mark); got.SavedItemIDs != strconv.FormatInt(first, 10) {
+		t.Fatalf("unexpected saved ids: %q", got.SavedItemIDs)
+	}
+
+	// Only entries published before the timestamp are marked.
+	mark = url.Values{"api_key": {testFeverKey}, "mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {strconv.FormatInt(jan.Add(time.Hour).Unix(), 10)}}
+	if got := feverPost(t, api.URL, "", mark); got.UnreadItemIDs != strconv.FormatInt(second, 10) {
+		t.Fatalf("unexpected unread ids after marking: %q", got.UnreadItemIDs)
+	}
+	// Only group 0 means every feed; feed ids must name a feed.
+	for _, id := range []string{"0", "-1"} {
+		resp, err := http.PostForm(api.URL+"/fever/?api", url.Values{"api_key": {testFeverKey}, "mark": {"feed"}, "as": {"read"}, "id": {id}})
+		if err != nil {
+			t.Fatalf("mark feed %s: %v", id, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("mark feed %s: status %d, want 400", id, resp.StatusCode)
+		}
+	}
+	if got := feverPost(t, api.URL, "&unread_item_ids", url.Values{"api_key": {testFeverKey}}); got.UnreadItemIDs != strconv.FormatInt(second, 10) {
+		t.Fatalf("invalid feed ids marked entries read: unread %q", got.UnreadItemIDs)
+	}
+	mark = url.Values{"api_key": {testFeverKey}, "mark": {"feed"}, "as": {"read"}, "id": {strconv.FormatInt(feed.ID, 10)}}
+	if got := feverPost(t, api.URL, "&unread_item_ids", mark); got.UnreadItemIDs != "" {
+		t.Fatalf("expected no unread items, got %q", got.UnreadItemIDs)
+	}
+}
+
+func TestNoJSONAPIKeepsFever(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "feed.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s := store.NewStore(db)
+	cfg := config.Config{FeverAPIKey: testFeverKey}
+	api := httptest.NewServer(New(s, fetch.NewFetcher(s, fetch.NewRenderer(), cfg), cfg, Options{NoJSONAPI: true}))
+	defer api.Close()
+
+	resp, err := http.Get(api.URL + "/v1/stats")
+	if err != nil {
+		t.Fatalf("stats request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("/v1/stats status = %d, want 404 with --no-json-api", resp.StatusCode)
+	}
+	if got := feverPost(t, api.URL, "", url.Values{"api_key": {testFeverKey}}); got.Auth != 1 {
+		t.Fatalf("expected Fever to stay available, got %+v", got)
+	}
+}
+
+func TestFeverDisabledWithoutKey(t *testing.T) {
+	api, _ := newTestServer(t, config.Config{})
+	resp, err := http.PostForm(api.URL+"/fever/?api", url.Values{"api_key": {""}})
+	if err != nil {
+		t.Fatalf("fever request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 when no key is configured", resp.StatusCode)
+	}
+}
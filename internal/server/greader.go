@@ -0,0 +1,778 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+)
+
+// Google Reader stream IDs and item ID prefix. Clients may write "user/-/"
+// or "user/<user id>/"; streams are normalized to the "-" form.
+const (
+	greaderReadingList = "user/-/state/com.google/reading-list"
+	greaderRead        = "user/-/state/com.google/read"
+	greaderStarred     = "user/-/state/com.google/starred"
+	greaderKeptUnread  = "user/-/state/com.google/kept-unread"
+	greaderLabelPrefix = "user/-/label/"
+	greaderFeedPrefix  = "feed/"
+	greaderItemPrefix  = "tag:google.com,2005:reader/item/"
+)
+
+const (
+	greaderDefaultCount = 20
+	greaderMaxIDs       = 10000
+	greaderMaxItems     = 1000
+	// greaderTokenTTL is how long a ClientLogin token stays valid; clients
+	// sign in again with the stored password when it lapses.
+	greaderTokenTTL = 30 * 24 * time.Hour
+)
+
+// greaderStreamContentsPath prefixes stream/contents requests, which
+// ServeHTTP routes itself; see there.
+const greaderStreamContentsPath = "/reader/api/0/stream/contents/"
+
+func (s *Server) greaderEnabled() bool {
+	return s.cfg.GReaderUsername != "" && s.cfg.GReaderPassword != ""
+}
+
+// greaderRoutes registers the Google Reader API as served by FreshRSS and
+// Miniflux, for clients such as NetNewsWire and FeedMe. Folders are labels,
+// and entry IDs are item IDs.
+func (s *Server) greaderRoutes() {
+	s.mux.HandleFunc("/accounts/ClientLogin", s.handleGReaderLogin)
+	for pattern, h := range map[string]http.HandlerFunc{
+		"/reader/api/0/token":                       s.handleGReaderToken,
+		"/reader/api/0/user-info":                   s.handleGReaderUserInfo,
+		"/reader/api/0/subscription/list":           s.handleGReaderSubscriptions,
+		"POST /reader/api/0/subscription/edit":      s.handleGReaderEditSubscription,
+		"POST /reader/api/0/subscription/quickadd":  s.handleGReaderQuickAdd,
+		"/reader/api/0/tag/list":                    s.handleGReaderTags,
+		"/reader/api/0/unread-count":                s.handleGReaderUnreadCount,
+		"/reader/api/0/stream/items/ids":            s.handleGReaderItemIDs,
+		"/reader/api/0/stream/items/contents":       s.handleGReaderItemContents,
+		"/reader/api/0/stream/contents/{stream...}": s.handleGReaderStreamContents,
+		"POST /reader/api/0/edit-tag":               s.handleGReaderEditTag,
+		"POST /reader/api/0/mark-all-as-read":       s.handleGReaderMarkAllRead,
+	} {
+		s.mux.Handle(pattern, s.greaderAuth(h))
+	}
+}
+
+// greaderToken is the auth token ClientLogin hands out: the username and an
+// expiry, signed with an HMAC keyed by the install's secret and the password.
+// Changing the password or deleting the secret file signs every client out.
+func (s *Server) greaderToken(expires time.Time) (string, error) {
+	secret, err := s.greaderSecret()
+	if err != nil {
+		return "", err
+	}
+	payload := s.cfg.GReaderUsername + "/" + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s.cfg.GReaderPassword + "\n" + payload))
+	return payload + "/" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// validGReaderToken reports whether token was issued by greaderToken and has
+// not expired.
+func (s *Server) validGReaderToken(token string) bool {
+	rest, _, ok := cutLast(token, "/")
+	if !ok {
+		return false
+	}
+	_, rawExpiry, ok := cutLast(rest, "/")
+	if !ok {
+		return false
+	}
+	expiry, err := strconv.ParseInt(rawExpiry, 10, 64)
+	if err != nil || time.Now().Unix() >= expiry {
+		return false
+	}
+	want, err := s.greaderToken(time.Unix(expiry, 0))
+	return err == nil && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// greaderSecret loads the HMAC key for auth tokens from greader.key next to
+// the database, creating it on first use.
+func (s *Server) greaderSecret() ([]byte, error) {
+	s.secretOnce.Do(func() {
+		s.secret, s.secretErr = loadSecret(filepath.Join(filepath.Dir(s.cfg.DBPath), "greader.key"))
+	})
+	return s.secret, s.secretErr
+}
+
+func loadSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(secret) < 32 {
+			return nil, fmt.Errorf("read %s: not a hex-encoded 32-byte key", path)
+		}
+		return secret, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate secret: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(secret)+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	return secret, nil
+}
+
+func (s *Server) handleGReaderLogin(w http.ResponseWriter, r *http.Request) {
+	// Credentials in a query string end up in logs and browser history.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", store.ErrInvalidInput, err))
+		return
+	}
+	user, pass := r.Form.Get("Email"), r.Form.Get("Passwd")
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.cfg.GReaderUsername)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(s.cfg.GReaderPassword)) == 1
+	if !userOK || !passOK {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	token, err := s.greaderToken(time.Now().Add(greaderTokenTTL))
+	if err != nil {
+		writeError(w, fmt.Errorf("issue token: %w", err))
+		return
+	}
+	if r.Form.Get("output") == "json" {
+		writeJSON(w, http.StatusOK, map[string]string{"SID": token, "LSID": token, "Auth": token})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=%s\nAuth=%s\n", token, token, token)
+}
+
+// greaderAuth requires the "Authorization: GoogleLogin auth=<token>" header
+// and parses the request form.
+func (s *Server) greaderAuth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.validGReaderToken(greaderAuthToken(r)) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeError(w, fmt.Errorf("%w: %v", store.ErrInvalidInput, err))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func greaderAuthToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+	return token
+}
+
+func writeGReaderOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("OK"))
+}
+
+func (s *Server) handleGReaderToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(greaderAuthToken(r)))
+}
+
+func (s *Server) handleGReaderUserInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"userId":        "1",
+		"userName":      s.cfg.GReaderUsername,
+		"userProfileId": "1",
+		"userEmail":     s.cfg.GReaderUsername,
+	})
+}
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type greaderSubscription struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Categories []greaderCategory `json:"categories"`
+	URL        string            `json:"url"`
+	HTMLURL    string            `json:"htmlUrl"`
+	IconURL    string            `json:"iconUrl"`
+}
+
+func (s *Server) handleGReaderSubscriptions(w http.ResponseWriter, r *http.Request) {
+	feeds, err := s.store.ListFeedsWithCounts(r.Context(), FeedListOptions{})
+	if err != nil {
+		writeError(w, fmt.Errorf("list feeds: %w", err))
+		return
+	}
+	subs := make([]greaderSubscription, 0, len(feeds))
+	for _, feed := range feeds {
+		sub := greaderSubscription{
+			ID:         greaderFeedID(feed.ID),
+			Title:      fallback(feed.Title, feed.URL),
+			Categories: []greaderCategory{},
+			URL:        feed.URL,
+			HTMLURL:    fallback(feed.SiteURL, feed.URL),
+		}
+		if feed.Folder != "" {
+			sub.Categories = append(sub.Categories, greaderCategory{ID: greaderLabelPrefix + feed.Folder, Label: feed.Folder})
+		}
+		subs = append(subs, sub)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptions": subs})
+}
+
+func (s *Server) handleGReaderTags(w http.ResponseWriter, r *http.Request) {
+	folders, err := s.store.ListFolders(r.Context())
+	if err != nil {
+		writeError(w, fmt.Errorf("list folders: %w", err))
+		return
+	}
+	tags := []greaderCategory{{ID: greaderStarred}}
+	for _, folder := range folders {
+		tags = append(tags, greaderCategory{ID: greaderLabelPrefix + folder.Name, Type: "folder"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+type greaderUnreadCount struct {
+	ID                      string `json:"id"`
+	Count                   int    `json:"count"`
+	NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+}
+
+func (s *Server) handleGReaderUnreadCount(w http.ResponseWriter, r *http.Request) {
+	feeds, err := s.store.ListFeedsWithCounts(r.Context(), FeedListOptions{})
+	if err != nil {
+		writeError(w, fmt.Errorf("list feeds: %w", err))
+		return
+	}
+	counts := make([]greaderUnreadCount, 0, len(feeds)+1)
+	byFolder := make(map[string]int)
+	total := 0
+	for _, feed := range feeds {
+		counts = append(counts, greaderUnreadCount{
+			ID:                      greaderFeedID(feed.ID),
+			Count:                   feed.UnreadCount,
+			NewestItemTimestampUsec: usec(feed.LastFetchedAt),
+		})
+		if feed.Folder != "" {
+			byFolder[feed.Folder] += feed.UnreadCount
+		}
+		total += feed.UnreadCount
+	}
+	for folder, n := range byFolder {
+		counts = append(counts, greaderUnreadCount{ID: greaderLabelPrefix + folder, Count: n, NewestItemTimestampUsec: "0"})
+	}
+	counts = append(counts, greaderUnreadCount{ID: greaderReadingList, Count: total, NewestItemTimestampUsec: "0"})
+	writeJSON(w, http.StatusOK, map[string]any{"max": total, "unreadcounts": counts})
+}
+
+func (s *Server) handleGReaderItemIDs(w http.ResponseWriter, r *http.Request) {
+	opts, err := s.greaderStreamOptions(r.Context(), r, r.Form.Get("s"), greaderMaxIDs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ids := []int64{}
+	if opts != nil {
+		if ids, err = s.store.ListEntryIDs(r.Context(), *opts); err != nil {
+			writeError(w, fmt.Errorf("list item ids: %w", err))
+			return
+		}
+	}
+	refs := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, map[string]string{"id": strconv.FormatInt(id, 10)})
+	}
+	resp := map[string]any{"itemRefs": refs}
+	if opts != nil && len(ids) == opts.Limit {
+		resp["continuation"] = strconv.FormatInt(ids[len(ids)-1], 10)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGReaderStreamContents(w http.ResponseWriter, r *http.Request) {
+	streamID := r.PathValue("stream")
+	if streamID == "" {
+		streamID = r.Form.Get("s")
+	}
+	opts, err := s.greaderStreamOptions(r.Context(), r, streamID, greaderMaxItems)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entries := []Entry{}
+	if opts != nil {
+		if entries, err = s.store.ListEntriesByID(r.Context(), *opts); err != nil {
+			writeError(w, fmt.Errorf("list items: %w", err))
+			return
+		}
+	}
+	resp, err := s.greaderItemsResponse(r.Context(), streamID, entries)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if opts != nil && len(entries) == opts.Limit {
+		resp["continuation"] = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGReaderItemContents(w http.ResponseWriter, r *http.Request) {
+	ids, err := greaderItemIDs(r.Form["i"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entries := []Entry{}
+	if len(ids) > 0 {
+		if entries, err = s.store.ListEntriesByID(r.Context(), EntryIDListOptions{IDs: ids, Limit: len(ids)}); err != nil {
+			writeError(w, fmt.Errorf("list items: %w", err))
+			return
+		}
+	}
+	resp, err := s.greaderItemsResponse(r.Context(), greaderReadingList, entries)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGReaderEditTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ids, err := greaderItemIDs(r.Form["i"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// Items pruned since the client last synced are skipped rather than
+	// failing the whole batch.
+	if len(ids) > 0 {
+		if ids, err = s.store.ListEntryIDs(ctx, EntryIDListOptions{IDs: ids}); err != nil {
+			writeError(w, fmt.Errorf("look up items: %w", err))
+			return
+		}
+	}
+	for _, change := range []struct {
+		tags []string
+		add  bool
+	}{{r.Form["a"], true}, {r.Form["r"], false}} {
+		for _, tag := range change.tags {
+			switch normalizeGReaderStream(tag) {
+			case greaderRead:
+				err = s.store.SetEntriesRead(ctx, ids, change.add)
+			case greaderKeptUnread:
+				err = s.store.SetEntriesRead(ctx, ids, !change.add)
+			case greaderStarred:
+				err = s.store.SetEntriesStarred(ctx, ids, change.add)
+			}
+			if err != nil {
+				writeError(w, fmt.Errorf("edit tag %s: %w", tag, err))
+				return
+			}
+		}
+	}
+	writeGReaderOK(w)
+}
+
+func (s *Server) handleGReaderMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	stream := normalizeGReaderStream(r.Form.Get("s"))
+	opts := MarkReadOptions{}
+	if ts, err := strconv.ParseInt(r.Form.Get("ts"), 10, 64); err == nil && ts > 0 {
+		t := time.UnixMicro(ts).UTC()
+		opts.Before = &t
+	}
+	switch {
+	case stream == greaderReadingList:
+	case strings.HasPrefix(stream, greaderFeedPrefix):
+		feedID, err := s.greaderFeed(ctx, stream)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.FeedID = feedID
+	case strings.HasPrefix(stream, greaderLabelPrefix):
+		folderID, err := s.greaderFolderID(ctx, strings.TrimPrefix(stream, greaderLabelPrefix))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.FolderID = folderID
+	default:
+		writeError(w, fmt.Errorf("%w: cannot mark stream %q as read", store.ErrInvalidInput, r.Form.Get("s")))
+		return
+	}
+	if _, err := s.store.MarkEntriesRead(ctx, opts); err != nil {
+		writeError(w, fmt.Errorf("mark all as read: %w", err))
+		return
+	}
+	writeGReaderOK(w)
+}
+
+func (s *Server) handleGReaderEditSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	title := r.Form.Get("t")
+	addLabel := strings.TrimPrefix(normalizeGReaderStream(r.Form.Get("a")), greaderLabelPrefix)
+	removeLabel := strings.TrimPrefix(normalizeGReaderStream(r.Form.Get("r")), greaderLabelPrefix)
+	streams := r.Form["s"]
+	if len(streams) == 0 {
+		writeError(w, fmt.Errorf("%w: missing subscription (s)", store.ErrInvalidInput))
+		return
+	}
+	for _, stream := range streams {
+		var err error
+		switch r.Form.Get("ac") {
+		case "subscribe":
+			_, err = s.greaderSubscribe(ctx, strings.TrimPrefix(stream, greaderFeedPrefix), addLabel, title)
+		case "unsubscribe":
+			var feedID int64
+			if feedID, err = s.greaderFeed(ctx, stream); err == nil {
+				err = s.store.DeleteFeed(ctx, feedID)
+			}
+		case "edit":
+			var feedID int64
+			if feedID, err = s.greaderFeed(ctx, stream); err == nil {
+				err = s.greaderEditFeed(ctx, feedID, title, addLabel, removeLabel)
+			}
+		default:
+			err = fmt.Errorf("%w: invalid ac %q (expected subscribe|unsubscribe|edit)", store.ErrInvalidInput, r.Form.Get("ac"))
+		}
+		if err != nil {
+			writeError(w, fmt.Errorf("edit subscription %s: %w", stream, err))
+			return
+		}
+	}
+	writeGReaderOK(w)
+}
+
+func (s *Server) handleGReaderQuickAdd(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimPrefix(strings.TrimSpace(r.Form.Get("quickadd")), greaderFeedPrefix)
+	if query == "" {
+		writeError(w, fmt.Errorf("%w: missing quickadd URL", store.ErrInvalidInput))
+		return
+	}
+	feed, err := s.greaderSubscribe(r.Context(), query, "", "")
+	if err != nil {
+		writeError(w, fmt.Errorf("subscribe to %s: %w", query, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"numResults": 1,
+		"query":      query,
+		"streamId":   greaderFeedID(feed.ID),
+		"streamName": fallback(feed.Title, feed.URL),
+	})
+}
+
+// greaderSubscribe adds a feed the way `feed add feed` does: discover the feed
+// URL, file it in a folder, and fetch it once.
+func (s *Server) greaderSubscribe(ctx context.Context, rawURL, folder, title string) (Feed, error) {
+	added, err := s.fetcher.AddFeed(ctx, rawURL, folder)
+	if err != nil {
+		return Feed{}, err
+	}
+	if title == "" {
+		return added.Feed, nil
+	}
+	if err := s.greaderEditFeed(ctx, added.Feed.ID, title, "", ""); err != nil {
+		return Feed{}, err
+	}
+	return s.store.GetFeedByID(ctx, added.Feed.ID)
+}
+
+// greaderEditFeed renames a feed and moves it between folders. Removing a
+// label without adding another takes the feed out of its folder.
+func (s *Server) greaderEditFeed(ctx context.Context, feedID int64, title, addLabel, removeLabel string) error {
+	if title != "" {
+		if _, err := s.store.UpdateFeed(ctx, feedID, UpdateFeedInput{CustomTitle: &title}); err != nil {
+			return fmt.Errorf("rename feed: %w", err)
+		}
+	}
+	switch {
+	case addLabel != "":
+		if err := s.store.SetFeedFolder(ctx, feedID, addLabel); err != nil {
+			return fmt.Errorf("set feed folder: %w", err)
+		}
+	case removeLabel != "":
+		if err := s.store.SetFeedFolder(ctx, feedID, ""); err != nil {
+			return fmt.Errorf("clear feed folder: %w", err)
+		}
+	}
+	return nil
+}
+
+// greaderStreamOptions translates a stream ID and the n, r, c, ot, nt, xt and
+// it parameters into list options. It returns nil when the filters cannot
+// match anything, such as the read stream with read items excluded.
+func (s *Server) greaderStreamOptions(ctx context.Context, r *http.Request, streamID string, maxCount int) (*EntryIDListOptions, error) {
+	opts := EntryIDListOptions{Limit: greaderDefaultCount, Descending: r.Form.Get("r") != "o"}
+	read, starred := "", false
+	stream := normalizeGReaderStream(streamID)
+	switch {
+	case stream == greaderReadingList:
+	case stream == greaderStarred:
+		starred = true
+	case stream == greaderRead:
+		read = "read"
+	case strings.HasPrefix(stream, greaderFeedPrefix):
+		feedID, err := s.greaderFeed(ctx, stream)
+		if err != nil {
+			return nil, err
+		}
+		opts.FeedID = feedID
+	case strings.HasPrefix(stream, greaderLabelPrefix):
+		opts.Folder = strings.TrimPrefix(stream, greaderLabelPrefix)
+	default:
+		return nil, fmt.Errorf("%w: unsupported stream %q", store.ErrInvalidInput, streamID)
+	}
+	if normalizeGReaderStream(r.Form.Get("xt")) == greaderRead {
+		if read == "read" {
+			return nil, nil
+		}
+		read = "unread"
+	}
+	if normalizeGReaderStream(r.Form.Get("it")) == greaderStarred {
+		starred = true
+	}
+	status := []string{}
+	if read != "" {
+		status = append(status, read)
+	}
+	if starred {
+		status = append(status, "starred")
+	}
+	opts.Status = strings.Join(status, "+")
+
+	if raw := r.Form.Get("n"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%w: invalid n %q", store.ErrInvalidInput, raw)
+		}
+		opts.Limit = min(n, maxCount)
+	}
+	if raw := r.Form.Get("c"); raw != "" {
+		after, err := parseID(raw)
+		if err != nil {
+			return nil, fmt.Errorf("continuation: %w", err)
+		}
+		if opts.Descending {
+			opts.MaxID = after
+		} else {
+			opts.SinceID = after
+		}
+	}
+	for key, dest := range map[string]**time.Time{"ot": &opts.Since, "nt": &opts.Until} {
+		if ts, err := strconv.ParseInt(r.Form.Get(key), 10, 64); err == nil && ts > 0 {
+			t := time.Unix(ts, 0).UTC()
+			*dest = &t
+		}
+	}
+	return &opts, nil
+}
+
+// greaderFeed resolves a "feed/<id>" stream, or "feed/<url>" as some clients
+// send for subscriptions they added themselves.
+func (s *Server) greaderFeed(ctx context.Context, stream string) (int64, error) {
+	ref := strings.TrimPrefix(stream, greaderFeedPrefix)
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil && id > 0 {
+		return id, nil
+	}
+	feed, err := s.store.GetFeedByURL(ctx, ref)
+	if err != nil {
+		return 0, fmt.Errorf("feed %q: %w", ref, err)
+	}
+	return feed.ID, nil
+}
+
+func (s *Server) greaderFolderID(ctx context.Context, name string) (int64, error) {
+	folders, err := s.store.ListFolders(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list folders: %w", err)
+	}
+	for _, folder := range folders {
+		if strings.EqualFold(folder.Name, name) {
+			return folder.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("folder %q: %w", name, store.ErrNotFound)
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type greaderOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type greaderItem struct {
+	ID            string         `json:"id"`
+	CrawlTimeMsec string         `json:"crawlTimeMsec"`
+	TimestampUsec string         `json:"timestampUsec"`
+	Published     int64          `json:"published"`
+	Updated       int64          `json:"updated"`
+	Title         string         `json:"title"`
+	Author        string         `json:"author,omitempty"`
+	Canonical     []greaderLink  `json:"canonical"`
+	Alternate     []greaderLink  `json:"alternate"`
+	Categories    []string       `json:"categories"`
+	Origin        greaderOrigin  `json:"origin"`
+	Summary       greaderContent `json:"summary"`
+}
+
+func (s *Server) greaderItemsResponse(ctx context.Context, streamID string, entries []Entry) (map[string]any, error) {
+	feeds, err := s.store.ListFeedsWithCounts(ctx, FeedListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list feeds: %w", err)
+	}
+	byID := make(map[int64]Feed, len(feeds))
+	for _, feed := range feeds {
+		byID[feed.ID] = feed
+	}
+	items := make([]greaderItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, newGReaderItem(e, byID[e.FeedID]))
+	}
+	return map[string]any{
+		"direction": "ltr",
+		"id":        streamID,
+		"updated":   time.Now().Unix(),
+		"items":     items,
+	}, nil
+}
+
+func newGReaderItem(e Entry, feed Feed) greaderItem {
+	published := e.FetchedAt
+	if e.PublishedAt != nil {
+		published = *e.PublishedAt
+	}
+	updated := published
+	if e.DateModified != nil {
+		updated = *e.DateModified
+	}
+	html := e.FullContentHTML
+	if html == "" {
+		html = e.ContentHTML
+	}
+	if html == "" {
+		html = e.Summary
+	}
+	link := fallback(e.URL, e.ExternalURL)
+
+	categories := []string{greaderReadingList}
+	if e.Read {
+		categories = append(categories, greaderRead)
+	}
+	if e.Starred {
+		categories = append(categories, greaderStarred)
+	}
+	if feed.Folder != "" {
+		categories = append(categories, greaderLabelPrefix+feed.Folder)
+	}
+	return greaderItem{
+		ID:            fmt.Sprintf("%s%016x", greaderItemPrefix, e.ID),
+		CrawlTimeMsec: strconv.FormatInt(e.FetchedAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(published.UnixMicro(), 10),
+		Published:     published.Unix(),
+		Updated:       updated.Unix(),
+		Title:         e.Title,
+		Author:        e.Author,
+		Canonical:     []greaderLink{{Href: link}},
+		Alternate:     []greaderLink{{Href: link, Type: "text/html"}},
+		Categories:    categories,
+		Origin: greaderOrigin{
+			StreamID: greaderFeedID(e.FeedID),
+			Title:    e.FeedTitle,
+			HTMLURL:  fallback(feed.SiteURL, feed.URL),
+		},
+		Summary: greaderContent{Direction: "ltr", Content: html},
+	}
+}
+
+// greaderItemIDs parses item IDs in the long form
+// ("tag:google.com,2005:reader/item/<16 hex digits>") or the short decimal
+// form.
+func greaderItemIDs(raw []string) ([]int64, error) {
+	ids := make([]int64, 0, len(raw))
+	for _, v := range raw {
+		var id int64
+		var err error
+		if hexID, ok := strings.CutPrefix(v, greaderItemPrefix); ok {
+			var u uint64
+			u, err = strconv.ParseUint(hexID, 16, 64)
+			id = int64(u)
+		} else {
+			id, err = strconv.ParseInt(v, 10, 64)
+		}
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("%w: invalid item id %q", store.ErrInvalidInput, v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// normalizeGReaderStream rewrites "user/<user id>/..." to "user/-/...".
+func normalizeGReaderStream(stream string) string {
+	rest, ok := strings.CutPrefix(stream, "user/")
+	if !ok {
+		return stream
+	}
+	if _, tail, found := strings.Cut(rest, "/"); found {
+		return "user/-/" + tail
+	}
+	return stream
+}
+
+func greaderFeedID(id int64) string {
+	return greaderFeedPrefix + strconv.FormatInt(id, 10)
+}
+
+func usec(t *time.Time) string {
+	if t == nil {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
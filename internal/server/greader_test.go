@@ -0,0 +1,249 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/odysseus0/feed/internal/config"
+)
+
+type greaderClient struct {
+	t     *testing.T
+	base  string
+	token string
+}
+
+func (c greaderClient) do(method, path string, form url.Values, out any) string {
+	c.t.Helper()
+	var body io.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(form.Encode())
+	} else if len(form) > 0 {
+		path += "?" + form.Encode()
+	}
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		c.t.Fatalf("new request: %v", err)
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("Authorization", "GoogleLogin auth="+c.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		c.t.Fatalf("%s %s: status %d: %s", method, path, resp.StatusCode, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.t.Fatalf("decode %s: %v", data, err)
+		}
+	}
+	return string(data)
+}
+
+type greaderItemRefs struct {
+	ItemRefs []struct {
+		ID string `json:"id"`
+	} `json:"itemRefs"`
+	Continuation string `json:"continuation"`
+}
+
+func (r greaderItemRefs) ids() []string {
+	ids := make([]string, 0, len(r.ItemRefs))
+	for _, ref := range r.ItemRefs {
+		ids = append(ids, ref.ID)
+	}
+	return ids
+}
+
+func TestGReaderAPI(t *testing.T) {
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`))
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = w.Write([]byte(testFeedXML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer feedSrv.Close()
+	api, _ := newTestServer(t, config.Config{GReaderUsername: "me", GReaderPassword: "s3cret"})
+
+	resp, err := http.PostForm(api.URL+"/accounts/ClientLogin", url.Values{"Email": {"me"}, "Passwd": {"wrong"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad login status = %d, want 401", resp.StatusCode)
+	}
+	resp, err = http.PostForm(api.URL+"/accounts/ClientLogin", url.Values{"Email": {"me"}, "Passwd": {"s3cret"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	_, token, ok := strings.Cut(strings.TrimSpace(string(data)), "Auth=")
+	if resp.StatusCode != http.StatusOK || !ok || token == "" {
+		t.Fatalf("login response %d: %s", resp.StatusCode, data)
+	}
+
+	anon := greaderClient{t: t, base: api.URL, token: "nope"}
+	req, _ := http.NewRequest(http.MethodGet, anon.base+"/reader/api/0/subscription/list", nil)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %v, %v", resp, err)
+	}
+
+	c := greaderClient{t: t, base: api.URL, token: token}
+	var added struct {
+		StreamID string `json:"streamId"`
+	}
+	c.do(http.MethodPost, "/reader/api/0/subscription/quickadd", url.Values{"quickadd": {feedSrv.URL}}, &added)
+	if added.StreamID != "feed/1" {
+		t.Fatalf("quickadd stream = %q, want feed/1", added.StreamID)
+	}
+	c.do(http.MethodPost, "/reader/api/0/subscription/edit", url.Values{
+		"ac": {"edit"}, "s": {"feed/1"}, "a": {"user/-/label/Tech"}, "t": {"Renamed"},
+	}, nil)
+
+	var subs struct {
+		Subscriptions []greaderSubscription `json:"subscriptions"`
+	}
+	c.do(http.MethodGet, "/reader/api/0/subscription/list", url.Values{"output": {"json"}}, &subs)
+	if len(subs.Subscriptions) != 1 || subs.Subscriptions[0].Title != "Renamed" ||
+		len(subs.Subscriptions[0].Categories) != 1 || subs.Subscriptions[0].Categories[0].Label != "Tech" {
+		t.Fatalf("unexpected subscriptions: %+v", subs)
+	}
+
+	var unread greaderItemRefs
+	c.do(http.MethodGet, "/reader/api/0/stream/items/ids", url.Values{
+		"s": {greaderReadingList}, "xt": {greaderRead}, "n": {"1"},
+	}, &unread)
+	if len(unread.ItemRefs) != 1 || unread.Continuation == "" {
+		t.Fatalf("expected one unread id and a continuation, got %+v", unread)
+	}
+	var next greaderItemRefs
+	c.do(http.MethodGet, "/reader/api/0/stream/items/ids", url.Values{
+		"s": {"user/-/label/Tech"}, "xt": {greaderRead}, "n": {"1"}, "c": {unread.Continuation},
+	}, &next)
+	if len(next.ItemRefs) != 1 || next.ItemRefs[0].ID == unread.ItemRefs[0].ID {
+		t.Fatalf("expected the next page to hold the other entry, got %+v", next)
+	}
+
+	var id int64
+	fmt.Sscan(unread.ItemRefs[0].ID, &id)
+	longID := fmt.Sprintf("%s%016x", greaderItemPrefix, id)
+	var contents struct {
+		Items []greaderItem `json:"items"`
+	}
+	c.do(http.MethodPost, "/reader/api/0/stream/items/contents", url.Values{"i": {longID}}, &contents)
+	if len(contents.Items) != 1 || contents.Items[0].ID != longID || contents.Items[0].Origin.StreamID != "feed/1" {
+		t.Fatalf("unexpected item contents: %+v", contents)
+	}
+
+	c.do(http.MethodPost, "/reader/api/0/edit-tag", url.Values{
+		"i": {longID, "999999"}, "a": {greaderRead, "user/1/state/com.google/starred"},
+	}, nil)
+	var starred greaderItemRefs
+	c.do(http.MethodGet, "/reader/api/0/stream/items/ids", url.Values{"s": {greaderStarred}}, &starred)
+	if got := starred.ids(); len(got) != 1 || got[0] != unread.ItemRefs[0].ID {
+		t.Fatalf("starred ids = %v, want [%s]", got, unread.ItemRefs[0].ID)
+	}
+
+	c.do(http.MethodGet, "/reader/api/0/stream/contents/"+url.PathEscape(greaderReadingList), url.Values{"xt": {greaderRead}}, &contents)
+	if len(contents.Items) != 1 || slices.Contains(contents.Items[0].Categories, greaderRead) {
+		t.Fatalf("expected only the unread item in the stream, got %+v", contents.Items)
+	}
+
+	// Clients may send a URL-form stream ID without encoding it.
+	c.do(http.MethodGet, "/reader/api/0/stream/contents/feed/"+feedSrv.URL+"/feed.xml", nil, &contents)
+	if len(contents.Items) != 2 || contents.Items[0].Origin.StreamID != "feed/1" {
+		t.Fatalf("expected the feed's items for an unencoded stream ID, got %+v", contents.Items)
+	}
+
+	c.do(http.MethodPost, "/reader/api/0/mark-all-as-read", url.Values{"s": {"user/-/label/Tech"}}, nil)
+	var counts struct {
+		UnreadCounts []greaderUnreadCount `json:"unreadcounts"`
+	}
+	c.do(http.MethodGet, "/reader/api/0/unread-count", nil, &counts)
+	for _, count := range counts.UnreadCounts {
+		if count.Count != 0 {
+			t.Fatalf("expected no unread entries after mark-all-as-read, got %+v", counts.UnreadCounts)
+		}
+	}
+
+	c.do(http.MethodPost, "/reader/api/0/subscription/edit", url.Values{"ac": {"unsubscribe"}, "s": {"feed/1"}}, nil)
+	c.do(http.MethodGet, "/reader/api/0/subscription/list", nil, &subs)
+	if len(subs.Subscriptions) != 0 {
+		t.Fatalf("expected no subscriptions after unsubscribe, got %+v", subs)
+	}
+}
+
+func TestGReaderTokens(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{DBPath: filepath.Join(dir, "feed.db"), GReaderUsername: "me", GReaderPassword: "s3cret"}
+	srv := New(nil, nil, cfg, Options{})
+	api := httptest.NewServer(srv)
+	defer api.Close()
+
+	status := func(method, path, token string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, api.URL+path, nil)
+		req.Header.Set("Authorization", "GoogleLogin auth="+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got := status(http.MethodGet, "/accounts/ClientLogin?Email=me&Passwd=s3cret", ""); got != http.StatusMethodNotAllowed {
+		t.Fatalf("GET ClientLogin status = %d, want 405", got)
+	}
+
+	valid, err := srv.greaderToken(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	expired, _ := srv.greaderToken(time.Now().Add(-time.Minute))
+	if got := status(http.MethodGet, "/reader/api/0/token", valid); got != http.StatusOK {
+		t.Fatalf("valid token status = %d, want 200", got)
+	}
+	for name, token := range map[string]string{
+		"expired":  expired,
+		"tampered": strings.Replace(valid, "me/", "you/", 1),
+		"unsigned": "me/" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + "/00",
+	} {
+		if got := status(http.MethodGet, "/reader/api/0/token", token); got != http.StatusUnauthorized {
+			t.Fatalf("%s token status = %d, want 401", name, got)
+		}
+	}
+
+	// Another install has its own secret, so its tokens are not accepted here.
+	other := New(nil, nil, config.Config{DBPath: filepath.Join(t.TempDir(), "feed.db"), GReaderUsername: "me", GReaderPassword: "s3cret"}, Options{})
+	foreign, _ := other.greaderToken(time.Now().Add(time.Hour))
+	if got := status(http.MethodGet, "/reader/api/0/token", foreign); got != http.StatusUnauthorized {
+		t.Fatalf("foreign token status = %d, want 401", got)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "greader.key")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private greader.key next to the database, got %v, %v", info, err)
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/odysseus0/feed/internal/store"
+	"github.com/odysseus0/feed/internal/timerange"
+)
+
+// maxBodyBytes bounds request bodies; the API only accepts small JSON objects.
+const maxBodyBytes = 1 << 20
+
+// Server exposes the store over a versioned JSON API. Responses use the same
+// shapes as `feed -o json`. The Fever API is served under /fever/ when an API
+// key is configured, and the Google Reader API under /accounts/ and
+// /reader/api/0/ when a username and password are. Requests for foreign host
+// names or from other sites' pages are refused, and /v1 requires a bearer
+// token when one is configured.
+type Server struct {
+	store   *Store
+	fetcher *Fetcher
+	cfg     Config
+	opts    Options
+	mux     *http.ServeMux
+
+	secretOnce sync.Once
+	secret     []byte
+	secretErr  error
+}
+
+func New(s *Store, f *Fetcher, cfg Config, opts Options) *Server {
+	srv := &Server{store: s, fetcher: f, cfg: cfg, opts: opts, mux: http.NewServeMux()}
+	srv.routes()
+	return srv
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkAccess(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	// An unencoded feed/https://… stream ID holds a "//" that ServeMux would
+	// clean away with a redirect, so the stream is taken from the raw path.
+	if stream, ok := strings.CutPrefix(r.URL.Path, greaderStreamContentsPath); ok && s.greaderEnabled() {
+		r.SetPathValue("stream", stream)
+		s.greaderAuth(s.handleGReaderStreamContents).ServeHTTP(w, r)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() {
+	if !s.opts.NoJSONAPI {
+		s.mux.HandleFunc("GET /v1/entries", s.requireToken(s.handleListEntries))
+		s.mux.HandleFunc("PATCH /v1/entries", s.requireToken(s.handleUpdateEntries))
+		s.mux.HandleFunc("GET /v1/entries/{id}", s.requireToken(s.handleGetEntry))
+		s.mux.HandleFunc("GET /v1/search", s.requireToken(s.handleSearch))
+		s.mux.HandleFunc("GET /v1/feeds", s.requireToken(s.handleListFeeds))
+		s.mux.HandleFunc("GET /v1/stats", s.requireToken(s.handleStats))
+		s.mux.HandleFunc("POST /v1/fetch", s.requireToken(s.handleFetch))
+	}
+	if s.cfg.FeverAPIKey != "" {
+		s.mux.HandleFunc("/fever", s.handleFever)
+		s.mux.HandleFunc("/fever/", s.handleFever)
+	}
+	if s.greaderEnabled() {
+		s.greaderRoutes()
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, fmt.Errorf("%w: no route for %s %s", store.ErrNotFound, r.Method, r.URL.Path))
+	})
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError maps store errors to HTTP statuses, using the same codes the CLI
+// prints in its error messages.
+func writeError(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, "internal"
+	switch {
+	case errors.Is(err, store.ErrInvalidInput):
+		status, code = http.StatusBadRequest, "invalid-input"
+	case errors.Is(err, store.ErrNotFound):
+		status, code = http.StatusNotFound, "not-found"
+	case errors.Is(err, store.ErrConflict):
+		status, code = http.StatusConflict, "conflict"
+	case errors.Is(err, errUnauthorized):
+		status, code = http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, errForbidden):
+		status, code = http.StatusForbidden, "forbidden"
+	}
+	writeJSON(w, status, errorResponse{Error: errorBody{Code: code, Message: err.Error()}})
+}
+
+// decodeBody decodes a JSON request body into v. An empty body leaves v
+// unchanged when optional is set.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any, optional bool) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) && optional {
+			return nil
+		}
+		return fmt.Errorf("%w: request body: %v", store.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+func parseID(raw string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid id %q", store.ErrInvalidInput, raw)
+	}
+	return id, nil
+}
+
+// queryID reads an optional positive ID parameter; a missing one is 0.
+func queryID(q url.Values, key string) (int64, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := parseID(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return id, nil
+}
+
+func queryLimit(q url.Values) (int, error) {
+	raw := q.Get("limit")
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: invalid limit %q", store.ErrInvalidInput, raw)
+	}
+	return n, nil
+}
+
+// queryTimeRange reads the optional since and until bounds, which take the
+// same values as the CLI's --since and --until.
+func queryTimeRange(q url.Values) (since, until *time.Time, err error) {
+	now := time.Now()
+	if since, err = timerange.Since(q.Get("since"), now); err != nil {
+		return nil, nil, fmt.Errorf("%w: since: %v", store.ErrInvalidInput, err)
+	}
+	if until, err = timerange.Until(q.Get("until"), now); err != nil {
+		return nil, nil, fmt.Errorf("%w: until: %v", store.ErrInvalidInput, err)
+	}
+	return since, until, nil
+}
+
+// queryTags accepts tags as repeated parameters or comma-separated values.
+func queryTags(q url.Values) []string {
+	var tags []string
+	for _, v := range q["tag"] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
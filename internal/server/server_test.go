@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/odysseus0/feed/internal/config"
+	"github.com/odysseus0/feed/internal/fetch"
+	"github.com/odysseus0/feed/internal/model"
+	"github.com/odysseus0/feed/internal/store"
+)
+
+const testFeedXML = `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<title>API Feed</title><link>https://example.com</link><description>desc</description>
+<item><guid>a</guid><title>Rust async</title><link>https://example.com/a</link><description>tokio runtime notes</description></item>
+<item><guid>b</guid><title>Go generics</title><link>https://example.com/b</link><description>type parameters</description></item>
+</channel></rss>`
+
+func newTestServer(t *testing.T, cfg config.Config) (*httptest.Server, *store.Store) {
+	t.Helper()
+	cfg.DBPath = filepath.Join(t.TempDir(), "feed.db")
+	db, err := store.OpenDB(cfg.DBPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s := store.NewStore(db)
+	cfg.HTTPTimeout = 5 * time.Second
+	cfg.FetchConcurrency = 2
+	cfg.UserAgent = "feed-test/1.0"
+	api := httptest.NewServer(New(s, fetch.NewFetcher(s, fetch.NewRenderer(), cfg), cfg, Options{}))
+	t.Cleanup(api.Close)
+	return api, s
+}
+
+func doJSON(t *testing.T, method, url, body string, wantStatus int, out any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, url, resp.StatusCode, wantStatus, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("decode %s: %v", data, err)
+		}
+	}
+}
+
+func TestServerEntriesFlow(t *testing.T) {
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testFeedXML))
+	}))
+	defer feedSrv.Close()
+
+	api, s := newTestServer(t, config.Config{})
+	feed, _, err := s.CreateFeed(context.Background(), feedSrv.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+
+	var rep model.FetchReport
+	doJSON(t, http.MethodPost, api.URL+"/v1/fetch", `{"force": true}`, http.StatusOK, &rep)
+	if len(rep.Results) != 1 || rep.Results[0].NewEntries != 2 {
+		t.Fatalf("unexpected fetch report: %+v", rep)
+	}
+
+	var entries []model.Entry
+	doJSON(t, http.MethodGet, api.URL+"/v1/entries?feed="+strconv.FormatInt(feed.ID, 10), "", http.StatusOK, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 unread entries, got %d", len(entries))
+	}
+
+	var found []model.Entry
+	doJSON(t, http.MethodGet, api.URL+"/v1/search?q=tokio", "", http.StatusOK, &found)
+	if len(found) != 1 || found[0].Title != "Rust async" {
+		t.Fatalf("unexpected search result: %+v", found)
+	}
+
+	id := found[0].ID
+	var update model.BatchUpdateEntriesResponse
+	doJSON(t, http.MethodPatch, api.URL+"/v1/entries", `{"ids": [`+strconv.FormatInt(id, 10)+`], "read": true, "starred": true}`, http.StatusOK, &update)
+	if update.Updated != 1 || update.Read == nil || !*update.Read || update.Starred == nil || !*update.Starred {
+		t.Fatalf("unexpected update response: %+v", update)
+	}
+
+	var entry model.Entry
+	doJSON(t, http.MethodGet, api.URL+"/v1/entries/"+strconv.FormatInt(id, 10), "", http.StatusOK, &entry)
+	if !entry.Read || !entry.Starred {
+		t.Fatalf("expected entry to be read and starred: %+v", entry)
+	}
+
+	var stats model.Stats
+	doJSON(t, http.MethodGet, api.URL+"/v1/stats", "", http.StatusOK, &stats)
+	if stats.Feeds != 1 || stats.Unread != 1 || stats.Starred != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	var feeds []model.Feed
+	doJSON(t, http.MethodGet, api.URL+"/v1/feeds", "", http.StatusOK, &feeds)
+	if len(feeds) != 1 || feeds[0].UnreadCount != 1 {
+		t.Fatalf("unexpected feeds: %+v", feeds)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	api, _ := newTestServer(t, config.Config{})
+
+	cases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodGet, "/v1/entries/999", "", http.StatusNotFound, "not-found"},
+		{http.MethodGet, "/v1/entries/abc", "", http.StatusBadRequest, "invalid-input"},
+		{http.MethodGet, "/v1/entries?limit=-1", "", http.StatusBadRequest, "invalid-input"},
+		{http.MethodGet, "/v1/entries?since=yesterday", "", http.StatusBadRequest, "invalid-input"},
+		{http.MethodGet, "/v1/search", "", http.StatusBadRequest, "invalid-input"},
+		{http.MethodPatch, "/v1/entries", `{"ids": [1]}`, http.StatusBadRequest, "invalid-input"},
+		{http.MethodPatch, "/v1/entries", `{"ids": [1], "unread": true}`, http.StatusBadRequest, "invalid-input"},
+		{http.MethodGet, "/v2/entries", "", http.StatusNotFound, "not-found"},
+	}
+	for _, tc := range cases {
+		var resp errorResponse
+		doJSON(t, tc.method, api.URL+tc.path, tc.body, tc.status, &resp)
+		if resp.Error.Code != tc.code || resp.Error.Message == "" {
+			t.Fatalf("%s %s: unexpected error body %+v", tc.method, tc.path, resp)
+		}
+	}
+}
+
+func TestServerAccessChecks(t *testing.T) {
+	api, _ := newTestServer(t, config.Config{APIToken: "tok"})
+	status := func(method, path string, header http.Header, host string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, api.URL+path, nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		if header != nil {
+			req.Header = header
+		}
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	auth := http.Header{"Authorization": {"Bearer tok"}}
+
+	cases := []struct {
+		name   string
+		method string
+		header http.Header
+		host   string
+		want   int
+	}{
+		{"no token", http.MethodGet, nil, "", http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, http.Header{"Authorization": {"Bearer nope"}}, "", http.StatusUnauthorized},
+		{"token", http.MethodGet, auth, "", http.StatusOK},
+		{"localhost", http.MethodGet, auth, "localhost:7070", http.StatusOK},
+		{"rebound host name", http.MethodGet, auth, "attacker.example:7070", http.StatusForbidden},
+		{"cross-site post", http.MethodPost, http.Header{"Origin": {"https://attacker.example"}}, "", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		path := "/v1/stats"
+		if tc.method == http.MethodPost {
+			path = "/v1/fetch"
+		}
+		if got := status(tc.method, path, tc.header, tc.host); got != tc.want {
+			t.Fatalf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	if !LoopbackAddr("127.0.0.1:7070") || !LoopbackAddr("[::1]:7070") || !LoopbackAddr("localhost:7070") {
+		t.Fatalf("expected loopback addresses to be recognized")
+	}
+	if LoopbackAddr(":7070") || LoopbackAddr("0.0.0.0:7070") || LoopbackAddr("192.168.1.5:7070") {
+		t.Fatalf("expected non-loopback addresses to be rejected")
+	}
+}
@@ -4,7 +4,19 @@ import "github.com/odysseus0/feed/internal/model"
 
 type Feed = model.Feed
 type Entry = model.Entry
+type EntryRevision = model.EntryRevision
+type Enclosure = model.Enclosure
+type EntryEnclosure = model.EntryEnclosure
+type MediaFile = model.MediaFile
+type Folder = model.Folder
 type Stats = model.Stats
 type EntryListOptions = model.EntryListOptions
+type EntryIDListOptions = model.EntryIDListOptions
+type MarkReadOptions = model.MarkReadOptions
+type EnclosureListOptions = model.EnclosureListOptions
+type FeedListOptions = model.FeedListOptions
+type FetchOptions = model.FetchOptions
 type SearchOptions = model.SearchOptions
 type UpsertEntryInput = model.UpsertEntryInput
+type UpdateFeedInput = model.UpdateFeedInput
+type UpdateEntriesInput = model.UpdateEntriesInput
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// entrySort describes a keyset ordering of entries: rows are ordered by
+// group ascending, then key descending, then id descending. Both group and
+// key are SQL expressions evaluating to numbers.
+type entrySort struct {
+	name  string
+	group string
+	key   string
+}
+
+var (
+	// Published entries first (newest first), then undated entries by fetch time.
+	sortByPublished = entrySort{
+		name:  "published",
+		group: `CASE WHEN e.published_at IS NULL OR e.published_at = '' THEN 1 ELSE 0 END`,
+		key:   entryTimeExpr,
+	}
+	sortByStarred = entrySort{
+		name:  "starred",
+		group: `0`,
+		key:   `COALESCE(julianday(es.starred_at), 0)`,
+	}
+	// Best bm25 match first, ties broken by recency.
+	sortByRank = entrySort{
+		name:  "rank",
+		group: `bm25(entries_fts)`,
+		key:   entryTimeExpr,
+	}
+)
+
+func (o entrySort) columns() string {
+	return o.group + ` AS sort_group, ` + o.key + ` AS sort_key`
+}
+
+func (o entrySort) orderBy() string {
+	return ` ORDER BY sort_group, sort_key DESC, e.id DESC`
+}
+
+// after returns the WHERE clause selecting rows that sort strictly after the
+// cursor position.
+func (o entrySort) after(c entryCursor) (string, []any) {
+	clause := `(` + o.group + ` > ? OR (` + o.group + ` = ? AND (` + o.key + ` < ? OR (` + o.key + ` = ? AND e.id < ?))))`
+	return clause, []any{c.Group, c.Group, c.Key, c.Key, c.ID}
+}
+
+type entryCursor struct {
+	Sort  string  `json:"s"`
+	Group float64 `json:"g"`
+	Key   float64 `json:"k"`
+	ID    int64   `json:"id"`
+}
+
+func encodeCursor(c entryCursor) string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string, sort entrySort) (*entryCursor, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	var c entryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	if c.Sort != sort.name {
+		return nil, fmt.Errorf("%w: cursor was issued for a different ordering (%s, want %s)", ErrInvalidInput, c.Sort, sort.name)
+	}
+	return &c, nil
+}
+
+// scanSortedEntry scans an entry row followed by its sort_group and sort_key
+// columns and attaches the cursor pointing just past it.
+func scanSortedEntry(scanner rowScanner, sort entrySort) (Entry, error) {
+	var c entryCursor
+	entry, err := scanEntry(scanner, &c.Group, &c.Key)
+	if err != nil {
+		return Entry{}, err
+	}
+	c.Sort = sort.name
+	c.ID = entry.ID
+	entry.Cursor = encodeCursor(c)
+	return entry, nil
+}
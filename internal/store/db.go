@@ -19,6 +19,19 @@ var migrations = []migration{
 	{name: "0001_initial_schema", run: migrateInitialSchema},
 	{name: "0002_feed_error_columns", run: migrateFeedErrorColumns},
 	{name: "0003_fts_rebuild", run: migrateFTSRebuild},
+	{name: "0004_folders", run: migrateFolders},
+	{name: "0005_entry_tags", run: migrateEntryTags},
+	{name: "0006_feed_settings", run: migrateFeedSettings},
+	{name: "0007_fetch_schedule", run: migrateFetchSchedule},
+	{name: "0008_retry_after", run: migrateRetryAfter},
+	{name: "0009_entry_content_hash", run: migrateEntryContentHash},
+	{name: "0010_entry_revisions", run: migrateEntryRevisions},
+	{name: "0011_full_content", run: migrateFullContent},
+	{name: "0012_enclosures", run: migrateEnclosures},
+	{name: "0013_media_cache", run: migrateMediaCache},
+	{name: "0014_full_content_search", run: migrateFullContentSearch},
+	{name: "0015_media_attempts", run: migrateMediaAttempts},
+	{name: "0016_entry_source_hash", run: migrateEntrySourceHash},
 }
 
 func OpenDB(path string) (*sql.DB, error) {
@@ -212,7 +225,11 @@ func migrateFeedErrorColumns(tx *sql.Tx) error {
 }
 
 func hasFeedColumn(tx *sql.Tx, target string) (bool, error) {
-	rows, err := tx.Query(`PRAGMA table_info(feeds);`)
+	return hasColumn(tx, "feeds", target)
+}
+
+func hasColumn(tx *sql.Tx, table, target string) (bool, error) {
+	rows, err := tx.Query(`PRAGMA table_info(` + table + `);`)
 	if err != nil {
 		return false, err
 	}
@@ -240,3 +257,325 @@ func migrateFTSRebuild(tx *sql.Tx) error {
 	}
 	return nil
 }
+
+func migrateFolders(tx *sql.Tx) error {
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS folders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		return err
+	}
+
+	hasFolderID, err := hasFeedColumn(tx, "folder_id")
+	if err != nil {
+		return err
+	}
+	if !hasFolderID {
+		if _, err := tx.Exec(`ALTER TABLE feeds ADD COLUMN folder_id INTEGER REFERENCES folders(id) ON DELETE SET NULL;`); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_feeds_folder ON feeds(folder_id);`); err != nil {
+		return err
+	}
+	return nil
+}
+
+func migrateEntryTags(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS entry_tags (
+			entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (entry_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_entry_tags_tag ON entry_tags(tag);`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFeedSettings(tx *sql.Tx) error {
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{name: "user_title", ddl: `ALTER TABLE feeds ADD COLUMN user_title TEXT;`},
+		{name: "paused", ddl: `ALTER TABLE feeds ADD COLUMN paused BOOLEAN NOT NULL DEFAULT 0;`},
+		{name: "fetch_interval_minutes", ddl: `ALTER TABLE feeds ADD COLUMN fetch_interval_minutes INTEGER NOT NULL DEFAULT 0;`},
+	}
+	for _, c := range columns {
+		has, err := hasFeedColumn(tx, c.name)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := tx.Exec(c.ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFetchSchedule(tx *sql.Tx) error {
+	has, err := hasFeedColumn(tx, "next_fetch_at")
+	if err != nil {
+		return err
+	}
+	if !has {
+		if _, err := tx.Exec(`ALTER TABLE feeds ADD COLUMN next_fetch_at DATETIME;`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateRetryAfter(tx *sql.Tx) error {
+	has, err := hasFeedColumn(tx, "retry_after_at")
+	if err != nil {
+		return err
+	}
+	if !has {
+		if _, err := tx.Exec(`ALTER TABLE feeds ADD COLUMN retry_after_at DATETIME;`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateEntryContentHash adds the per-entry content hash used to skip
+// unchanged items, and narrows the FTS update trigger to the indexed columns
+// so bookkeeping updates (fetched_at, content_hash) leave the index alone.
+func migrateEntryContentHash(tx *sql.Tx) error {
+	has, err := hasColumn(tx, "entries", "content_hash")
+	if err != nil {
+		return err
+	}
+	if !has {
+		if _, err := tx.Exec(`ALTER TABLE entries ADD COLUMN content_hash TEXT;`); err != nil {
+			return err
+		}
+	}
+	stmts := []string{
+		`DROP TRIGGER IF EXISTS entries_au;`,
+		`CREATE TRIGGER entries_au AFTER UPDATE OF title, summary, content_md ON entries BEGIN
+			INSERT INTO entries_fts(entries_fts, rowid, title, summary, content_md)
+			VALUES ('delete', old.id, old.title, old.summary, old.content_md);
+			INSERT INTO entries_fts(rowid, title, summary, content_md)
+			VALUES (new.id, new.title, new.summary, new.content_md);
+		END;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateEntryRevisions(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS entry_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+			title TEXT,
+			content_html TEXT,
+			content_md TEXT,
+			fetched_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_entry_revisions_entry ON entry_revisions(entry_id, id);`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFullContent(tx *sql.Tx) error {
+	columns := []struct {
+		table string
+		name  string
+		ddl   string
+	}{
+		{table: "feeds", name: "full_content", ddl: `ALTER TABLE feeds ADD COLUMN full_content BOOLEAN NOT NULL DEFAULT 0;`},
+		{table: "entries", name: "full_content_html", ddl: `ALTER TABLE entries ADD COLUMN full_content_html TEXT;`},
+		{table: "entries", name: "full_content_md", ddl: `ALTER TABLE entries ADD COLUMN full_content_md TEXT;`},
+		{table: "entries", name: "full_content_fetched_at", ddl: `ALTER TABLE entries ADD COLUMN full_content_fetched_at DATETIME;`},
+	}
+	for _, c := range columns {
+		has, err := hasColumn(tx, c.table, c.name)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := tx.Exec(c.ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateEnclosures(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS enclosures (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			url TEXT NOT NULL,
+			mime_type TEXT,
+			length INTEGER,
+			duration_seconds INTEGER
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_enclosures_entry ON enclosures(entry_id, position);`,
+		`CREATE INDEX IF NOT EXISTS idx_enclosures_mime_type ON enclosures(mime_type);`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMediaCache(tx *sql.Tx) error {
+	columns := []struct {
+		table string
+		name  string
+		ddl   string
+	}{
+		{table: "feeds", name: "auto_download", ddl: `ALTER TABLE feeds ADD COLUMN auto_download BOOLEAN NOT NULL DEFAULT 0;`},
+		{table: "entries", name: "media_fetched_at", ddl: `ALTER TABLE entries ADD COLUMN media_fetched_at DATETIME;`},
+	}
+	for _, c := range columns {
+		has, err := hasColumn(tx, c.table, c.name)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := tx.Exec(c.ddl); err != nil {
+			return err
+		}
+	}
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS media_files (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+			url TEXT NOT NULL,
+			path TEXT NOT NULL UNIQUE,
+			kind TEXT NOT NULL,
+			mime_type TEXT,
+			size INTEGER NOT NULL,
+			downloaded_at DATETIME NOT NULL,
+			accessed_at DATETIME NOT NULL,
+			UNIQUE(entry_id, url)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_media_files_accessed ON media_files(accessed_at);`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateFullContentSearch adds extracted articles to the search index and
+// tracks failed extractions apart from successful ones, so failures are
+// retried a few times instead of never. Attempts recorded before this
+// migration stored failures as empty content; they are reset to retry.
+func migrateFullContentSearch(tx *sql.Tx) error {
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{name: "full_content_attempts", ddl: `ALTER TABLE entries ADD COLUMN full_content_attempts INTEGER NOT NULL DEFAULT 0;`},
+		{name: "full_content_error", ddl: `ALTER TABLE entries ADD COLUMN full_content_error TEXT;`},
+	}
+	for _, c := range columns {
+		has, err := hasColumn(tx, "entries", c.name)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := tx.Exec(c.ddl); err != nil {
+			return err
+		}
+	}
+	stmts := []string{
+		`UPDATE entries SET full_content_fetched_at = NULL, full_content_attempts = 1
+		WHERE full_content_fetched_at IS NOT NULL AND full_content_md IS NULL;`,
+		`DROP TRIGGER IF EXISTS entries_ai;`,
+		`DROP TRIGGER IF EXISTS entries_ad;`,
+		`DROP TRIGGER IF EXISTS entries_au;`,
+		`DROP TABLE IF EXISTS entries_fts;`,
+		`CREATE VIRTUAL TABLE entries_fts USING fts5(
+			title,
+			summary,
+			content_md,
+			full_content_md,
+			content=entries,
+			content_rowid=id
+		);`,
+		`CREATE TRIGGER entries_ai AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts(rowid, title, summary, content_md, full_content_md)
+			VALUES (new.id, new.title, new.summary, new.content_md, new.full_content_md);
+		END;`,
+		`CREATE TRIGGER entries_ad AFTER DELETE ON entries BEGIN
+			INSERT INTO entries_fts(entries_fts, rowid, title, summary, content_md, full_content_md)
+			VALUES ('delete', old.id, old.title, old.summary, old.content_md, old.full_content_md);
+		END;`,
+		`CREATE TRIGGER entries_au AFTER UPDATE OF title, summary, content_md, full_content_md ON entries BEGIN
+			INSERT INTO entries_fts(entries_fts, rowid, title, summary, content_md, full_content_md)
+			VALUES ('delete', old.id, old.title, old.summary, old.content_md, old.full_content_md);
+			INSERT INTO entries_fts(rowid, title, summary, content_md, full_content_md)
+			VALUES (new.id, new.title, new.summary, new.content_md, new.full_content_md);
+		END;`,
+		`INSERT INTO entries_fts(entries_fts) VALUES ('rebuild');`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMediaAttempts(tx *sql.Tx) error {
+	has, err := hasColumn(tx, "entries", "media_attempts")
+	if err != nil {
+		return err
+	}
+	if has {
+		return nil
+	}
+	_, err = tx.Exec(`ALTER TABLE entries ADD COLUMN media_attempts INTEGER NOT NULL DEFAULT 0;`)
+	return err
+}
+
+func migrateEntrySourceHash(tx *sql.Tx) error {
+	has, err := hasColumn(tx, "entries", "source_hash")
+	if err != nil {
+		return err
+	}
+	if has {
+		return nil
+	}
+	_, err = tx.Exec(`ALTER TABLE entries ADD COLUMN source_hash TEXT;`)
+	return err
+}
@@ -9,6 +9,7 @@ import (
 var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidInput = errors.New("invalid input")
+	ErrConflict     = errors.New("conflict")
 )
 
 func wrapNotFound(entity string, err error) error {
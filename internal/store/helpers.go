@@ -40,3 +40,10 @@ func truncate(v string, max int) string {
 	}
 	return v[:max]
 }
+
+func nullIfEmpty(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
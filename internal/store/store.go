@@ -2,6 +2,10 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -18,50 +22,33 @@ type rowScanner interface {
 }
 
 func scanFeedRow(scanner rowScanner) (Feed, error) {
-	var f Feed
-	var siteURL, title, desc, lastFetched, etag, lastMod, lastErr sql.NullString
-	var createdAt string
-	if err := scanner.Scan(
-		&f.ID,
-		&f.URL,
-		&siteURL,
-		&title,
-		&desc,
-		&lastFetched,
-		&etag,
-		&lastMod,
-		&lastErr,
-		&f.ErrorCount,
-		&createdAt,
-	); err != nil {
+	return scanFeed(scanner)
+}
+
+func scanFeedWithCountsRow(scanner rowScanner) (Feed, error) {
+	var unread, total int
+	f, err := scanFeed(scanner, &unread, &total)
+	if err != nil {
 		return Feed{}, err
 	}
-	f.SiteURL = siteURL.String
-	f.Title = title.String
-	f.Description = desc.String
-	f.ETag = etag.String
-	f.LastModified = lastMod.String
-	f.LastError = lastErr.String
-	if t, err := parseDBTime(createdAt); err == nil {
-		f.CreatedAt = t
-	}
-	if lastFetched.Valid {
-		if t, err := parseDBTime(lastFetched.String); err == nil {
-			f.LastFetchedAt = &t
-		}
-	}
+	f.UnreadCount = unread
+	f.TotalCount = total
 	return f, nil
 }
 
-func scanFeedWithCountsRow(scanner rowScanner) (Feed, error) {
+// scanFeed scans the feedBaseColumns projection followed by any extra
+// destinations selected after it.
+func scanFeed(scanner rowScanner, extra ...any) (Feed, error) {
 	var f Feed
-	var siteURL, title, desc, lastFetched, etag, lastMod, lastErr sql.NullString
+	var siteURL, title, customTitle, desc, lastFetched, etag, lastMod, lastErr, folder, nextFetch, retryAfter sql.NullString
+	var folderID sql.NullInt64
 	var createdAt string
-	if err := scanner.Scan(
+	dest := []any{
 		&f.ID,
 		&f.URL,
 		&siteURL,
 		&title,
+		&customTitle,
 		&desc,
 		&lastFetched,
 		&etag,
@@ -69,17 +56,27 @@ func scanFeedWithCountsRow(scanner rowScanner) (Feed, error) {
 		&lastErr,
 		&f.ErrorCount,
 		&createdAt,
-		&f.UnreadCount,
-		&f.TotalCount,
-	); err != nil {
+		&folderID,
+		&folder,
+		&f.Paused,
+		&f.FetchIntervalMinutes,
+		&f.FullContent,
+		&f.AutoDownload,
+		&nextFetch,
+		&retryAfter,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
 		return Feed{}, err
 	}
 	f.SiteURL = siteURL.String
 	f.Title = title.String
+	f.CustomTitle = customTitle.String
 	f.Description = desc.String
 	f.ETag = etag.String
 	f.LastModified = lastMod.String
 	f.LastError = lastErr.String
+	f.FolderID = folderID.Int64
+	f.Folder = folder.String
 	if t, err := parseDBTime(createdAt); err == nil {
 		f.CreatedAt = t
 	}
@@ -88,16 +85,28 @@ func scanFeedWithCountsRow(scanner rowScanner) (Feed, error) {
 			f.LastFetchedAt = &t
 		}
 	}
+	if nextFetch.Valid {
+		if t, err := parseDBTime(nextFetch.String); err == nil {
+			f.NextFetchAt = &t
+		}
+	}
+	if retryAfter.Valid {
+		if t, err := parseDBTime(retryAfter.String); err == nil {
+			f.RetryAfter = &t
+		}
+	}
 	return f, nil
 }
 
-func scanEntry(scanner rowScanner) (Entry, error) {
+func scanEntry(scanner rowScanner, extra ...any) (Entry, error) {
 	var e Entry
 	var feedTitle sql.NullString
 	var url, externalURL, title, summary, contentHTML, contentMD, author sql.NullString
-	var publishedAt, dateModified sql.NullString
+	var publishedAt, dateModified, tags sql.NullString
+	var fullHTML, fullMD, fullFetchedAt sql.NullString
+	var enclosures sql.NullString
 	var fetchedAt string
-	if err := scanner.Scan(
+	dest := []any{
 		&e.ID,
 		&e.FeedID,
 		&feedTitle,
@@ -114,7 +123,13 @@ func scanEntry(scanner rowScanner) (Entry, error) {
 		&fetchedAt,
 		&e.Read,
 		&e.Starred,
-	); err != nil {
+		&tags,
+		&fullHTML,
+		&fullMD,
+		&fullFetchedAt,
+		&enclosures,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
 		return Entry{}, err
 	}
 	e.FeedTitle = feedTitle.String
@@ -138,6 +153,22 @@ func scanEntry(scanner rowScanner) (Entry, error) {
 	if t, err := parseDBTime(fetchedAt); err == nil {
 		e.FetchedAt = t
 	}
+	if tags.String != "" {
+		e.Tags = strings.Split(tags.String, ",")
+		sort.Strings(e.Tags)
+	}
+	e.FullContentHTML = fullHTML.String
+	e.FullContentMD = fullMD.String
+	if fullFetchedAt.Valid {
+		if t, err := parseDBTime(fullFetchedAt.String); err == nil {
+			e.FullContentFetchedAt = &t
+		}
+	}
+	if enclosures.String != "" && enclosures.String != "[]" {
+		if err := json.Unmarshal([]byte(enclosures.String), &e.Enclosures); err != nil {
+			return Entry{}, fmt.Errorf("decode enclosures: %w", err)
+		}
+	}
 	return e, nil
 }
 
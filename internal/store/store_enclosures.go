@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ListEnclosures returns enclosures of the newest entries first. MimeType
+// matches either an exact type such as "audio/mpeg" or a major type given as
+// "audio" or "audio/*".
+func (s *Store) ListEnclosures(ctx context.Context, opts EnclosureListOptions) ([]EntryEnclosure, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	where := make([]string, 0, 2)
+	args := make([]any, 0, 3)
+	if opts.FeedID > 0 {
+		where = append(where, "e.feed_id = ?")
+		args = append(args, opts.FeedID)
+	}
+	if mime := strings.ToLower(strings.TrimSpace(opts.MimeType)); mime != "" {
+		major, minor, hasMinor := strings.Cut(mime, "/")
+		if major == "" || strings.ContainsAny(mime, "%_ ") || (hasMinor && minor == "") {
+			return nil, fmt.Errorf("%w: invalid MIME type %q (expected e.g. audio/mpeg, audio or audio/*)", ErrInvalidInput, opts.MimeType)
+		}
+		if !hasMinor || minor == "*" {
+			where = append(where, "LOWER(en.mime_type) LIKE ?")
+			args = append(args, major+"/%")
+		} else {
+			where = append(where, "LOWER(en.mime_type) = ?")
+			args = append(args, mime)
+		}
+	}
+
+	query := `
+		SELECT en.entry_id, e.feed_id, COALESCE(NULLIF(f.user_title, ''), NULLIF(f.title, ''), f.url),
+			e.title, e.published_at, en.url, en.mime_type, en.length, en.duration_seconds
+		FROM enclosures en
+		JOIN entries e ON e.id = en.entry_id
+		JOIN feeds f ON f.id = e.feed_id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY ` + entryTimeExpr + ` DESC, e.id DESC, en.position LIMIT ?`
+	args = append(args, opts.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]EntryEnclosure, 0)
+	for rows.Next() {
+		var item EntryEnclosure
+		var title, publishedAt, mimeType sql.NullString
+		var length, duration sql.NullInt64
+		if err := rows.Scan(&item.EntryID, &item.FeedID, &item.FeedTitle, &title, &publishedAt,
+			&item.URL, &mimeType, &length, &duration); err != nil {
+			return nil, err
+		}
+		item.EntryTitle = title.String
+		item.MimeType = mimeType.String
+		item.Length = length.Int64
+		item.DurationSeconds = int(duration.Int64)
+		if publishedAt.Valid {
+			if t, err := parseDBTime(publishedAt.String); err == nil {
+				item.PublishedAt = &t
+			}
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
@@ -2,92 +2,30 @@ package store
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 const entrySelectColumns = `
-	e.id, e.feed_id, COALESCE(NULLIF(f.title, ''), f.url), e.guid,
+	e.id, e.feed_id, COALESCE(NULLIF(f.user_title, ''), NULLIF(f.title, ''), f.url), e.guid,
 	e.url, e.external_url, e.title, e.summary, e.content_html, e.content_md,
 	e.author, e.published_at, e.date_modified, e.fetched_at,
-	COALESCE(es.read, 0), COALESCE(es.starred, 0)
+	COALESCE(es.read, 0), COALESCE(es.starred, 0),
+	(SELECT GROUP_CONCAT(et.tag, ',') FROM entry_tags et WHERE et.entry_id = e.id),
+	e.full_content_html, e.full_content_md, e.full_content_fetched_at,
+	(SELECT json_group_array(json_object(
+		'url', en.url, 'mime_type', en.mime_type, 'length', en.length, 'duration_seconds', en.duration_seconds
+	)) FROM (SELECT url, mime_type, length, duration_seconds FROM enclosures WHERE entry_id = e.id ORDER BY position) en)
 `
 
-func (s *Store) UpsertEntry(ctx context.Context, in UpsertEntryInput) (entryID int64, inserted bool, err error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, false, err
-	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
-
-	var existingID int64
-	err = tx.QueryRowContext(ctx, `SELECT id FROM entries WHERE feed_id = ? AND guid = ?`, in.FeedID, in.GUID).Scan(&existingID)
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		inserted = true
-	case err != nil:
-		return 0, false, err
-	}
-
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO entries (
-			feed_id, guid, url, external_url, title, summary,
-			content_html, content_md, author, published_at, date_modified
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(feed_id, guid) DO UPDATE SET
-			url = excluded.url,
-			external_url = excluded.external_url,
-			title = excluded.title,
-			summary = excluded.summary,
-			content_html = excluded.content_html,
-			content_md = excluded.content_md,
-			author = excluded.author,
-			published_at = excluded.published_at,
-			date_modified = excluded.date_modified,
-			fetched_at = CURRENT_TIMESTAMP
-	`,
-		in.FeedID,
-		in.GUID,
-		in.URL,
-		in.ExternalURL,
-		in.Title,
-		in.Summary,
-		in.ContentHTML,
-		in.ContentMD,
-		in.Author,
-		timeToDBString(in.PublishedAt),
-		timeToDBString(in.DateModified),
-	)
-	if err != nil {
-		return 0, false, err
-	}
-
-	if err = tx.QueryRowContext(ctx, `SELECT id FROM entries WHERE feed_id = ? AND guid = ?`, in.FeedID, in.GUID).Scan(&entryID); err != nil {
-		return 0, false, err
-	}
-	if _, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO entry_status(entry_id) VALUES (?)`, entryID); err != nil {
-		return 0, false, err
-	}
-
-	if err = tx.Commit(); err != nil {
-		return 0, false, err
-	}
-	return entryID, inserted, nil
-}
-
 func (s *Store) ListEntries(ctx context.Context, opts EntryListOptions) ([]Entry, error) {
 	if opts.Limit <= 0 {
 		opts.Limit = 50
 	}
-	status := strings.ToLower(strings.TrimSpace(opts.Status))
-	if status == "" {
-		status = "unread"
+	statusWhere, starred, err := statusFilter(opts.Status)
+	if err != nil {
+		return nil, err
 	}
 
 	where := make([]string, 0, 2)
@@ -96,26 +34,56 @@ func (s *Store) ListEntries(ctx context.Context, opts EntryListOptions) ([]Entry
 		where = append(where, "e.feed_id = ?")
 		args = append(args, opts.FeedID)
 	}
-	switch status {
-	case "unread":
-		where = append(where, "COALESCE(es.read, 0) = 0")
-	case "read":
-		where = append(where, "COALESCE(es.read, 0) = 1")
-	case "all":
-	default:
-		return nil, fmt.Errorf("%w: invalid status %q (expected unread|read|all)", ErrInvalidInput, opts.Status)
+	if strings.TrimSpace(opts.Folder) != "" {
+		folderID, err := s.folderIDByName(ctx, opts.Folder)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "f.folder_id = ?")
+		args = append(args, folderID)
+	}
+	tagWhere, tagArgs, err := tagFilter(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, tagWhere...)
+	args = append(args, tagArgs...)
+	timeWhere, timeArgs, err := timeRangeFilter(opts.Since, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, timeWhere...)
+	args = append(args, timeArgs...)
+	where = append(where, statusWhere...)
+
+	sort := sortByPublished
+	if starred {
+		sort = sortByStarred
+	}
+	cursor, err := decodeCursor(opts.After, sort)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		clause, cursorArgs := sort.after(*cursor)
+		where = append(where, clause)
+		args = append(args, cursorArgs...)
 	}
 
-	query := `SELECT ` + entrySelectColumns + `
+	query := `SELECT ` + entrySelectColumns + `, ` + sort.columns() + `
 		FROM entries e
 		JOIN feeds f ON f.id = e.feed_id
 		LEFT JOIN entry_status es ON es.entry_id = e.id`
 	if len(where) > 0 {
 		query += ` WHERE ` + strings.Join(where, " AND ")
 	}
-	query += ` ORDER BY CASE WHEN e.published_at IS NULL OR e.published_at = '' THEN 1 ELSE 0 END, COALESCE(e.published_at, e.fetched_at) DESC LIMIT ?`
+	query += sort.orderBy() + ` LIMIT ?`
 	args = append(args, opts.Limit)
 
+	return s.querySortedEntries(ctx, sort, query, args...)
+}
+
+func (s *Store) querySortedEntries(ctx context.Context, sort entrySort, query string, args ...any) ([]Entry, error) {
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
@@ -124,7 +92,7 @@ func (s *Store) ListEntries(ctx context.Context, opts EntryListOptions) ([]Entry
 
 	entries := make([]Entry, 0)
 	for rows.Next() {
-		entry, err := scanEntry(rows)
+		entry, err := scanSortedEntry(rows, sort)
 		if err != nil {
 			return nil, err
 		}
@@ -133,6 +101,66 @@ func (s *Store) ListEntries(ctx context.Context, opts EntryListOptions) ([]Entry
 	return entries, rows.Err()
 }
 
+// entryTimeExpr is the timestamp used for date-range filters: the published
+// time, falling back to when the entry was fetched.
+const entryTimeExpr = `julianday(COALESCE(NULLIF(e.published_at, ''), e.fetched_at))`
+
+func timeRangeFilter(since, until *time.Time) ([]string, []any, error) {
+	if since != nil && until != nil && until.Before(*since) {
+		return nil, nil, fmt.Errorf("%w: --until must not be before --since", ErrInvalidInput)
+	}
+	where := make([]string, 0, 2)
+	args := make([]any, 0, 2)
+	if since != nil {
+		where = append(where, entryTimeExpr+" >= julianday(?)")
+		args = append(args, timeToDBString(since))
+	}
+	if until != nil {
+		where = append(where, entryTimeExpr+" <= julianday(?)")
+		args = append(args, timeToDBString(until))
+	}
+	return where, args, nil
+}
+
+// statusFilter parses a status such as "unread", "starred" or "unread+starred"
+// into WHERE clauses. starred reports whether results should be ordered by
+// starred_at rather than publication time.
+func statusFilter(raw string) (where []string, starred bool, err error) {
+	status := strings.ToLower(strings.TrimSpace(raw))
+	if status == "" {
+		status = "unread"
+	}
+
+	var read, unread bool
+	for _, part := range strings.Split(status, "+") {
+		switch strings.TrimSpace(part) {
+		case "unread":
+			unread = true
+		case "read":
+			read = true
+		case "starred":
+			starred = true
+		case "all":
+		default:
+			return nil, false, fmt.Errorf("%w: invalid status %q (expected unread|read|starred|all, or combinations like unread+starred)", ErrInvalidInput, raw)
+		}
+	}
+	if read && unread {
+		return nil, false, fmt.Errorf("%w: invalid status %q (read and unread are mutually exclusive)", ErrInvalidInput, raw)
+	}
+
+	switch {
+	case unread:
+		where = append(where, "COALESCE(es.read, 0) = 0")
+	case read:
+		where = append(where, "COALESCE(es.read, 0) = 1")
+	}
+	if starred {
+		where = append(where, "COALESCE(es.starred, 0) = 1")
+	}
+	return where, starred, nil
+}
+
 func (s *Store) GetEntry(ctx context.Context, id int64) (Entry, error) {
 	row := s.db.QueryRowContext(ctx, `
 		SELECT `+entrySelectColumns+`
@@ -162,34 +190,41 @@ func (s *Store) SearchEntries(ctx context.Context, opts SearchOptions) ([]Entry,
 		where = append(where, "e.feed_id = ?")
 		args = append(args, opts.Feed)
 	}
+	tagWhere, tagArgs, err := tagFilter(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, tagWhere...)
+	args = append(args, tagArgs...)
+	timeWhere, timeArgs, err := timeRangeFilter(opts.Since, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, timeWhere...)
+	args = append(args, timeArgs...)
+
+	cursor, err := decodeCursor(opts.After, sortByRank)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		clause, cursorArgs := sortByRank.after(*cursor)
+		where = append(where, clause)
+		args = append(args, cursorArgs...)
+	}
 
 	query := `
-		SELECT ` + entrySelectColumns + `
+		SELECT ` + entrySelectColumns + `, ` + sortByRank.columns() + `
 		FROM entries_fts
 		JOIN entries e ON e.id = entries_fts.rowid
 		JOIN feeds f ON f.id = e.feed_id
 		LEFT JOIN entry_status es ON es.entry_id = e.id
-		WHERE ` + strings.Join(where, " AND ") + `
-		ORDER BY bm25(entries_fts), COALESCE(e.published_at, e.fetched_at) DESC
+		WHERE ` + strings.Join(where, " AND ") + sortByRank.orderBy() + `
 		LIMIT ?
 	`
 	args = append(args, opts.Limit)
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	entries := make([]Entry, 0)
-	for rows.Next() {
-		entry, err := scanEntry(rows)
-		if err != nil {
-			return nil, err
-		}
-		entries = append(entries, entry)
-	}
-	return entries, rows.Err()
+	return s.querySortedEntries(ctx, sortByRank, query, args...)
 }
 
 func (s *Store) GetStats(ctx context.Context) (Stats, error) {
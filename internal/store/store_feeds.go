@@ -3,10 +3,22 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
-const feedBaseColumns = `id, url, site_url, title, description, last_fetched_at, etag, last_modified, last_error, error_count, created_at`
+// postingIntervalSample is how many recent entries FeedPostingInterval looks at.
+const postingIntervalSample = 20
+
+const feedBaseColumns = `f.id, f.url, f.site_url, COALESCE(NULLIF(f.user_title, ''), f.title), f.user_title, f.description, f.last_fetched_at, f.etag, f.last_modified, f.last_error, f.error_count, f.created_at, f.folder_id, fo.name, f.paused, f.fetch_interval_minutes, f.full_content, f.auto_download, f.next_fetch_at, f.retry_after_at`
+
+// feedDisplayTitle is the title shown for a feed: the user's custom title,
+// then the title from the feed document, then its URL.
+const feedDisplayTitle = `COALESCE(NULLIF(f.user_title, ''), NULLIF(f.title, ''), f.url)`
+
+const feedBaseFrom = `feeds f LEFT JOIN folders fo ON fo.id = f.folder_id`
 
 func (s *Store) CreateFeed(ctx context.Context, url string) (Feed, bool, error) {
 	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO feeds(url) VALUES (?)`, url)
@@ -25,7 +37,7 @@ func (s *Store) CreateFeed(ctx context.Context, url string) (Feed, bool, error)
 }
 
 func (s *Store) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT `+feedBaseColumns+` FROM feeds WHERE url = ?`, url)
+	row := s.db.QueryRowContext(ctx, `SELECT `+feedBaseColumns+` FROM `+feedBaseFrom+` WHERE f.url = ?`, url)
 	feed, err := scanFeedRow(row)
 	if err != nil {
 		return Feed{}, wrapNotFound("feed", err)
@@ -34,7 +46,7 @@ func (s *Store) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 }
 
 func (s *Store) GetFeedByID(ctx context.Context, id int64) (Feed, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT `+feedBaseColumns+` FROM feeds WHERE id = ?`, id)
+	row := s.db.QueryRowContext(ctx, `SELECT `+feedBaseColumns+` FROM `+feedBaseFrom+` WHERE f.id = ?`, id)
 	feed, err := scanFeedRow(row)
 	if err != nil {
 		return Feed{}, wrapNotFound("feed", err)
@@ -54,28 +66,34 @@ func (s *Store) DeleteFeed(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (s *Store) ListFeedsWithCounts(ctx context.Context) ([]Feed, error) {
-	rows, err := s.db.QueryContext(ctx, `
+func (s *Store) ListFeedsWithCounts(ctx context.Context, opts FeedListOptions) ([]Feed, error) {
+	where := make([]string, 0, 1)
+	args := make([]any, 0, 1)
+	if strings.TrimSpace(opts.Folder) != "" {
+		folderID, err := s.folderIDByName(ctx, opts.Folder)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "f.folder_id = ?")
+		args = append(args, folderID)
+	}
+
+	query := `
 		SELECT
-			f.id,
-			f.url,
-			f.site_url,
-			f.title,
-			f.description,
-			f.last_fetched_at,
-			f.etag,
-			f.last_modified,
-			f.last_error,
-			f.error_count,
-			f.created_at,
+			` + feedBaseColumns + `,
 			COALESCE(SUM(CASE WHEN e.id IS NOT NULL AND COALESCE(es.read, 0) = 0 THEN 1 ELSE 0 END), 0) AS unread_count,
 			COUNT(e.id) AS total_count
-		FROM feeds f
+		FROM ` + feedBaseFrom + `
 		LEFT JOIN entries e ON e.feed_id = f.id
-		LEFT JOIN entry_status es ON es.entry_id = e.id
+		LEFT JOIN entry_status es ON es.entry_id = e.id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += `
 		GROUP BY f.id
-		ORDER BY COALESCE(NULLIF(f.title, ''), f.url) COLLATE NOCASE
-	`)
+		ORDER BY ` + feedDisplayTitle + ` COLLATE NOCASE`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,14 +110,30 @@ func (s *Store) ListFeedsWithCounts(ctx context.Context) ([]Feed, error) {
 	return feeds, rows.Err()
 }
 
-func (s *Store) ListFeedsForFetch(ctx context.Context, id *int64) ([]Feed, error) {
-	query := `SELECT ` + feedBaseColumns + ` FROM feeds`
-	args := make([]any, 0, 1)
-	if id != nil {
-		query += ` WHERE id = ?`
-		args = append(args, *id)
+func (s *Store) ListFeedsForFetch(ctx context.Context, opts FetchOptions) ([]Feed, error) {
+	where := make([]string, 0, 2)
+	args := make([]any, 0, 2)
+	if opts.FeedID != nil {
+		where = append(where, "f.id = ?")
+		args = append(args, *opts.FeedID)
+	} else {
+		// Paused feeds are only fetched when requested explicitly by ID.
+		where = append(where, "f.paused = 0")
 	}
-	query += ` ORDER BY id`
+	if strings.TrimSpace(opts.Folder) != "" {
+		folderID, err := s.folderIDByName(ctx, opts.Folder)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "f.folder_id = ?")
+		args = append(args, folderID)
+	}
+
+	query := `SELECT ` + feedBaseColumns + ` FROM ` + feedBaseFrom
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY f.id`
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -118,7 +152,7 @@ func (s *Store) ListFeedsForFetch(ctx context.Context, id *int64) ([]Feed, error
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	if id != nil && len(feeds) == 0 {
+	if opts.FeedID != nil && len(feeds) == 0 {
 		return nil, ErrNotFound
 	}
 	return feeds, nil
@@ -135,12 +169,134 @@ func (s *Store) UpdateFeedFetchSuccess(ctx context.Context, feedID int64, title,
 			last_modified = ?,
 			last_fetched_at = ?,
 			last_error = NULL,
-			error_count = 0
+			error_count = 0,
+			retry_after_at = NULL
 		WHERE id = ?
 	`, title, title, siteURL, siteURL, description, description, etag, lastModified, fetchedAt.UTC().Format(time.RFC3339Nano), feedID)
 	return err
 }
 
+// UpdateFeed applies the non-nil settings in one statement, so either all of
+// them take effect or none do. A new URL keeps the feed's entries and their
+// status; conditional request state, error counters, Retry-After and the
+// backoff schedule are reset since they belonged to the old URL. It returns
+// ErrConflict if another feed already uses the new URL.
+func (s *Store) UpdateFeed(ctx context.Context, id int64, in UpdateFeedInput) (feed Feed, err error) {
+	sets := make([]string, 0, 8)
+	args := make([]any, 0, 6)
+	if in.URL != nil {
+		if strings.TrimSpace(*in.URL) == "" {
+			return Feed{}, fmt.Errorf("%w: feed url is required", ErrInvalidInput)
+		}
+		sets = append(sets, "url = ?", "etag = NULL", "last_modified = NULL", "last_error = NULL",
+			"error_count = 0", "retry_after_at = NULL", "next_fetch_at = NULL")
+		args = append(args, *in.URL)
+	}
+	if in.CustomTitle != nil {
+		sets = append(sets, "user_title = ?")
+		args = append(args, nullIfEmpty(strings.TrimSpace(*in.CustomTitle)))
+	}
+	if in.Paused != nil {
+		sets = append(sets, "paused = ?")
+		args = append(args, *in.Paused)
+	}
+	if in.FetchIntervalMinutes != nil {
+		if *in.FetchIntervalMinutes < 0 {
+			return Feed{}, fmt.Errorf("%w: fetch interval must be >= 0", ErrInvalidInput)
+		}
+		sets = append(sets, "fetch_interval_minutes = ?")
+		args = append(args, *in.FetchIntervalMinutes)
+	}
+	if in.FullContent != nil {
+		sets = append(sets, "full_content = ?")
+		args = append(args, *in.FullContent)
+	}
+	if in.AutoDownload != nil {
+		sets = append(sets, "auto_download = ?")
+		args = append(args, *in.AutoDownload)
+	}
+	if len(sets) == 0 {
+		return s.GetFeedByID(ctx, id)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Feed{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if in.URL != nil {
+		var otherID int64
+		err = tx.QueryRowContext(ctx, `SELECT id FROM feeds WHERE url = ? AND id <> ?`, *in.URL, id).Scan(&otherID)
+		switch {
+		case err == nil:
+			return Feed{}, fmt.Errorf("%w: %s is already subscribed as feed %d", ErrConflict, *in.URL, otherID)
+		case !errors.Is(err, sql.ErrNoRows):
+			return Feed{}, err
+		}
+	}
+
+	args = append(args, id)
+	res, err := tx.ExecContext(ctx, `UPDATE feeds SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+	if err != nil {
+		return Feed{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Feed{}, fmt.Errorf("feed: %w", ErrNotFound)
+	}
+	if err = tx.Commit(); err != nil {
+		return Feed{}, err
+	}
+	return s.GetFeedByID(ctx, id)
+}
+
+// UpdateFeedURL points a feed at a new URL; see UpdateFeed.
+func (s *Store) UpdateFeedURL(ctx context.Context, id int64, newURL string) error {
+	_, err := s.UpdateFeed(ctx, id, UpdateFeedInput{URL: &newURL})
+	return err
+}
+
+// SetFeedNextFetchAt records when the feed is next due for a scheduled fetch.
+func (s *Store) SetFeedNextFetchAt(ctx context.Context, feedID int64, next time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE feeds SET next_fetch_at = ? WHERE id = ?`, next.UTC().Format(time.RFC3339Nano), feedID)
+	return err
+}
+
+// SetFeedRetryAfter records a server-requested "do not fetch before" time,
+// cleared again by the next successful fetch.
+func (s *Store) SetFeedRetryAfter(ctx context.Context, feedID int64, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE feeds SET retry_after_at = ? WHERE id = ?`, until.UTC().Format(time.RFC3339Nano), feedID)
+	return err
+}
+
+// FeedPostingInterval returns the mean gap between the feed's most recent
+// dated entries, or zero when there are fewer than two to compare.
+func (s *Store) FeedPostingInterval(ctx context.Context, feedID int64) (time.Duration, error) {
+	var count int
+	var spanDays sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(t) - MIN(t)
+		FROM (
+			SELECT julianday(published_at) AS t
+			FROM entries
+			WHERE feed_id = ? AND published_at IS NOT NULL AND published_at <> ''
+			ORDER BY published_at DESC
+			LIMIT ?
+		)
+	`, feedID, postingIntervalSample).Scan(&count, &spanDays)
+	if err != nil {
+		return 0, err
+	}
+	if count < 2 || !spanDays.Valid {
+		return 0, nil
+	}
+	return time.Duration(spanDays.Float64 * float64(24*time.Hour) / float64(count-1)), nil
+}
+
 func (s *Store) SetFeedError(ctx context.Context, feedID int64, errMsg string) error {
 	_, err := s.db.ExecContext(ctx, `
 		UPDATE feeds
@@ -150,6 +306,9 @@ func (s *Store) SetFeedError(ctx context.Context, feedID int64, errMsg string) e
 	return err
 }
 
+// GetFetchStaleness reports whether an implicit fetch is worthwhile: the
+// latest fetch is older than staleAfter and at least one unpaused feed is
+// due by its schedule and any Retry-After.
 func (s *Store) GetFetchStaleness(ctx context.Context, staleAfter time.Duration) (hasFeeds bool, stale bool, lastFetched *time.Time, err error) {
 	var count int
 	var maxFetched sql.NullString
@@ -160,24 +319,54 @@ func (s *Store) GetFetchStaleness(ctx context.Context, staleAfter time.Duration)
 		return false, false, nil, nil
 	}
 	hasFeeds = true
-	if !maxFetched.Valid {
-		return hasFeeds, true, nil, nil
+	if maxFetched.Valid {
+		if t, parseErr := parseDBTime(maxFetched.String); parseErr == nil {
+			lastFetched = &t
+		}
+	}
+	if lastFetched != nil && time.Since(*lastFetched) <= staleAfter {
+		return hasFeeds, false, lastFetched, nil
+	}
+
+	feeds, err := s.ListFeedsForFetch(ctx, FetchOptions{})
+	if err != nil {
+		return hasFeeds, false, lastFetched, err
 	}
+	now := time.Now()
+	for _, feed := range feeds {
+		if FeedDue(feed, now) && !FeedRetryPending(feed, now) {
+			return hasFeeds, true, lastFetched, nil
+		}
+	}
+	return hasFeeds, false, lastFetched, nil
+}
 
-	t, parseErr := parseDBTime(maxFetched.String)
-	if parseErr != nil {
-		return hasFeeds, true, nil, nil
+// FeedDue reports whether a scheduled fetch may pick feed up at now: its
+// per-feed minimum refresh interval has passed and its scheduled next fetch
+// time has arrived.
+func FeedDue(feed Feed, now time.Time) bool {
+	if feed.FetchIntervalMinutes > 0 && feed.LastFetchedAt != nil {
+		next := feed.LastFetchedAt.Add(time.Duration(feed.FetchIntervalMinutes) * time.Minute)
+		if now.Before(next) {
+			return false
+		}
 	}
-	lastFetched = &t
-	stale = time.Since(t) > staleAfter
-	return hasFeeds, stale, lastFetched, nil
+	return feed.NextFetchAt == nil || !now.Before(*feed.NextFetchAt)
+}
+
+// FeedRetryPending reports whether the feed's server asked via Retry-After
+// not to be contacted before a time still ahead of now. Unlike FeedDue this
+// holds even for forced fetches.
+func FeedRetryPending(feed Feed, now time.Time) bool {
+	return feed.RetryAfter != nil && now.Before(*feed.RetryAfter)
 }
 
 func (s *Store) ListFeedURLs(ctx context.Context) ([]Feed, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, url, COALESCE(NULLIF(title, ''), url), site_url
-		FROM feeds
-		ORDER BY COALESCE(NULLIF(title, ''), url) COLLATE NOCASE
+		SELECT f.id, f.url, `+feedDisplayTitle+`, f.site_url, fo.name
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		ORDER BY `+feedDisplayTitle+` COLLATE NOCASE
 	`)
 	if err != nil {
 		return nil, err
@@ -187,11 +376,12 @@ func (s *Store) ListFeedURLs(ctx context.Context) ([]Feed, error) {
 	feeds := make([]Feed, 0)
 	for rows.Next() {
 		var feed Feed
-		var siteURL sql.NullString
-		if err := rows.Scan(&feed.ID, &feed.URL, &feed.Title, &siteURL); err != nil {
+		var siteURL, folder sql.NullString
+		if err := rows.Scan(&feed.ID, &feed.URL, &feed.Title, &siteURL, &folder); err != nil {
 			return nil, err
 		}
 		feed.SiteURL = siteURL.String
+		feed.Folder = folder.String
 		feeds = append(feeds, feed)
 	}
 	return feeds, rows.Err()
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (s *Store) EnsureFolder(ctx context.Context, name string) (Folder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Folder{}, fmt.Errorf("%w: folder name must not be empty", ErrInvalidInput)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO folders(name) VALUES (?)`, name); err != nil {
+		return Folder{}, err
+	}
+	var folder Folder
+	if err := s.db.QueryRowContext(ctx, `SELECT id, name FROM folders WHERE name = ?`, name).Scan(&folder.ID, &folder.Name); err != nil {
+		return Folder{}, wrapNotFound("folder", err)
+	}
+	return folder, nil
+}
+
+func (s *Store) ListFolders(ctx context.Context) ([]Folder, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM folders ORDER BY name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := make([]Folder, 0)
+	for rows.Next() {
+		var folder Folder
+		if err := rows.Scan(&folder.ID, &folder.Name); err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, rows.Err()
+}
+
+// SetFeedFolder moves a feed into the named folder, creating it if needed.
+// An empty name removes the feed from its folder.
+func (s *Store) SetFeedFolder(ctx context.Context, feedID int64, name string) error {
+	var folderID any
+	if strings.TrimSpace(name) != "" {
+		folder, err := s.EnsureFolder(ctx, name)
+		if err != nil {
+			return err
+		}
+		folderID = folder.ID
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE feeds SET folder_id = ? WHERE id = ?`, folderID, feedID)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("feed: %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) folderIDByName(ctx context.Context, name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `SELECT id FROM folders WHERE name = ?`, name).Scan(&id); err != nil {
+		return 0, wrapNotFound(fmt.Sprintf("folder %q", name), err)
+	}
+	return id, nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxFullContentAttempts bounds how many fetches try to extract an entry's
+// article before giving up on the page.
+const maxFullContentAttempts = 3
+
+// SetEntryFullContent stores the article extracted from an entry's web page
+// next to the feed-provided content.
+func (s *Store) SetEntryFullContent(ctx context.Context, entryID int64, contentHTML, contentMD string, fetchedAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE entries
+		SET full_content_html = ?, full_content_md = ?, full_content_fetched_at = ?, full_content_error = NULL
+		WHERE id = ?
+	`, nullIfEmpty(contentHTML), nullIfEmpty(contentMD), fetchedAt.UTC().Format(time.RFC3339Nano), entryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("entry: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// RecordFullContentFailure counts a failed extraction of an entry's article.
+// Any previously extracted article is kept; the entry is retried until it
+// has failed maxFullContentAttempts times.
+func (s *Store) RecordFullContentFailure(ctx context.Context, entryID int64, message string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE entries
+		SET full_content_attempts = full_content_attempts + 1, full_content_error = ?
+		WHERE id = ?
+	`, nullIfEmpty(message), entryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("entry: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// ListEntriesMissingFullContent returns the newest entries of a feed that
+// have a URL but no extracted article, untried entries first, skipping those
+// that failed too often. Only ID and URL are populated.
+func (s *Store) ListEntriesMissingFullContent(ctx context.Context, feedID int64, limit int) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.url
+		FROM entries e
+		WHERE e.feed_id = ? AND e.full_content_fetched_at IS NULL AND COALESCE(e.url, '') <> ''
+			AND e.full_content_attempts < ?
+		ORDER BY e.full_content_attempts, `+entryTimeExpr+` DESC, e.id DESC
+		LIMIT ?
+	`, feedID, maxFullContentAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		e := Entry{FeedID: feedID}
+		if err := rows.Scan(&e.ID, &e.URL); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
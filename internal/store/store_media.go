@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const mediaFileColumns = `id, entry_id, url, path, kind, mime_type, size, downloaded_at, accessed_at`
+
+func scanMediaFile(scanner rowScanner) (MediaFile, error) {
+	var m MediaFile
+	var mimeType sql.NullString
+	var downloadedAt, accessedAt string
+	if err := scanner.Scan(&m.ID, &m.EntryID, &m.URL, &m.Path, &m.Kind, &mimeType, &m.Size, &downloadedAt, &accessedAt); err != nil {
+		return MediaFile{}, err
+	}
+	m.MimeType = mimeType.String
+	if t, err := parseDBTime(downloadedAt); err == nil {
+		m.DownloadedAt = t
+	}
+	if t, err := parseDBTime(accessedAt); err == nil {
+		m.AccessedAt = t
+	}
+	return m, nil
+}
+
+func (s *Store) queryMediaFiles(ctx context.Context, query string, args ...any) ([]MediaFile, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make([]MediaFile, 0)
+	for rows.Next() {
+		m, err := scanMediaFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, m)
+	}
+	return files, rows.Err()
+}
+
+// AddMediaFile records a downloaded file, replacing an earlier copy of the
+// same URL for the entry.
+func (s *Store) AddMediaFile(ctx context.Context, m MediaFile) error {
+	if err := s.ensureEntryExists(ctx, m.EntryID); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO media_files (entry_id, url, path, kind, mime_type, size, downloaded_at, accessed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(entry_id, url) DO UPDATE SET
+			path = excluded.path,
+			kind = excluded.kind,
+			mime_type = excluded.mime_type,
+			size = excluded.size,
+			downloaded_at = excluded.downloaded_at,
+			accessed_at = excluded.accessed_at
+	`, m.EntryID, m.URL, m.Path, m.Kind, nullIfEmpty(m.MimeType), m.Size, now, now)
+	return err
+}
+
+// ListEntryMedia returns the files downloaded for an entry.
+func (s *Store) ListEntryMedia(ctx context.Context, entryID int64) ([]MediaFile, error) {
+	return s.queryMediaFiles(ctx, `SELECT `+mediaFileColumns+` FROM media_files WHERE entry_id = ? ORDER BY id`, entryID)
+}
+
+// ListMediaFiles returns every cached file, least recently used first.
+func (s *Store) ListMediaFiles(ctx context.Context) ([]MediaFile, error) {
+	return s.queryMediaFiles(ctx, `SELECT `+mediaFileColumns+` FROM media_files ORDER BY julianday(accessed_at), id`)
+}
+
+// TouchEntryMedia marks an entry's files as used so cache eviction keeps
+// them longer.
+func (s *Store) TouchEntryMedia(ctx context.Context, entryID int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE media_files SET accessed_at = ? WHERE entry_id = ?`, at.UTC().Format(time.RFC3339Nano), entryID)
+	return err
+}
+
+func (s *Store) DeleteMediaFile(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM media_files WHERE id = ?`, id)
+	return err
+}
+
+// maxMediaAttempts bounds how many automatic downloads retry an entry whose
+// media could not all be downloaded.
+const maxMediaAttempts = 3
+
+// SetEntryMediaFetchedAt records that an entry's media were downloaded, so
+// automatic downloads skip it on later fetches.
+func (s *Store) SetEntryMediaFetchedAt(ctx context.Context, entryID int64, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE entries SET media_fetched_at = ? WHERE id = ?`, at.UTC().Format(time.RFC3339Nano), entryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("entry: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// RecordMediaFailure counts a download of an entry's media in which some
+// files failed. Automatic downloads retry the entry until it has failed
+// maxMediaAttempts times.
+func (s *Store) RecordMediaFailure(ctx context.Context, entryID int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE entries SET media_attempts = media_attempts + 1 WHERE id = ?`, entryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("entry: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// ListEntriesMissingMedia returns IDs of a feed's entries published since the
+// given time whose media have not all been downloaded yet, newest first,
+// skipping entries that failed too often.
+func (s *Store) ListEntriesMissingMedia(ctx context.Context, feedID int64, since time.Time, limit int) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id
+		FROM entries e
+		WHERE e.feed_id = ? AND e.media_fetched_at IS NULL AND `+entryTimeExpr+` >= julianday(?)
+			AND e.media_attempts < ?
+		ORDER BY `+entryTimeExpr+` DESC, e.id DESC
+		LIMIT ?
+	`, feedID, timeToDBString(&since), maxMediaAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ListEntryRevisions returns an entry's content history, oldest first, ending
+// with its current content.
+func (s *Store) ListEntryRevisions(ctx context.Context, entryID int64) ([]EntryRevision, error) {
+	current, err := s.GetEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT title, content_html, content_md, fetched_at
+		FROM entry_revisions
+		WHERE entry_id = ?
+		ORDER BY id
+	`, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]EntryRevision, 0)
+	for rows.Next() {
+		var title, contentHTML, contentMD sql.NullString
+		var fetchedAt string
+		if err := rows.Scan(&title, &contentHTML, &contentMD, &fetchedAt); err != nil {
+			return nil, err
+		}
+		rev := EntryRevision{
+			Revision:    len(revisions) + 1,
+			EntryID:     entryID,
+			Title:       title.String,
+			ContentHTML: contentHTML.String,
+			ContentMD:   contentMD.String,
+		}
+		if t, err := parseDBTime(fetchedAt); err == nil {
+			rev.FetchedAt = t
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(revisions, EntryRevision{
+		Revision:    len(revisions) + 1,
+		EntryID:     entryID,
+		Title:       current.Title,
+		ContentHTML: current.ContentHTML,
+		ContentMD:   current.ContentMD,
+		FetchedAt:   current.FetchedAt,
+		Current:     true,
+	}), nil
+}
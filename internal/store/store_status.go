@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 func (s *Store) ensureEntryStatus(ctx context.Context, id int64) error {
@@ -71,22 +72,52 @@ func (s *Store) ToggleEntryStarred(ctx context.Context, id int64) (bool, error)
 }
 
 func (s *Store) SetEntriesRead(ctx context.Context, ids []int64, read bool) error {
-	query := `UPDATE entry_status SET read = 0, read_at = NULL WHERE entry_id = ?`
+	return s.batchUpdateEntryStatus(ctx, ids, readQuery(read))
+}
+
+func (s *Store) SetEntriesStarred(ctx context.Context, ids []int64, starred bool) error {
+	return s.batchUpdateEntryStatus(ctx, ids, starredQuery(starred))
+}
+
+// UpdateEntries validates a read and/or starred change and applies it to
+// every entry in one transaction, so an unknown ID changes nothing.
+func (s *Store) UpdateEntries(ctx context.Context, in UpdateEntriesInput) error {
+	if len(in.IDs) == 0 {
+		return fmt.Errorf("%w: ids is required", ErrInvalidInput)
+	}
+	for _, id := range in.IDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: invalid id %d", ErrInvalidInput, id)
+		}
+	}
+	if in.Read == nil && in.Starred == nil {
+		return fmt.Errorf("%w: set read and/or starred", ErrInvalidInput)
+	}
+	var queries []string
+	if in.Read != nil {
+		queries = append(queries, readQuery(*in.Read))
+	}
+	if in.Starred != nil {
+		queries = append(queries, starredQuery(*in.Starred))
+	}
+	return s.batchUpdateEntryStatus(ctx, in.IDs, queries...)
+}
+
+func readQuery(read bool) string {
 	if read {
-		query = `UPDATE entry_status SET read = 1, read_at = CURRENT_TIMESTAMP WHERE entry_id = ?`
+		return `UPDATE entry_status SET read = 1, read_at = CURRENT_TIMESTAMP WHERE entry_id = ?`
 	}
-	return s.batchUpdateEntryStatus(ctx, ids, query)
+	return `UPDATE entry_status SET read = 0, read_at = NULL WHERE entry_id = ?`
 }
 
-func (s *Store) SetEntriesStarred(ctx context.Context, ids []int64, starred bool) error {
-	query := `UPDATE entry_status SET starred = 0, starred_at = NULL WHERE entry_id = ?`
+func starredQuery(starred bool) string {
 	if starred {
-		query = `UPDATE entry_status SET starred = 1, starred_at = CURRENT_TIMESTAMP WHERE entry_id = ?`
+		return `UPDATE entry_status SET starred = 1, starred_at = CURRENT_TIMESTAMP WHERE entry_id = ?`
 	}
-	return s.batchUpdateEntryStatus(ctx, ids, query)
+	return `UPDATE entry_status SET starred = 0, starred_at = NULL WHERE entry_id = ?`
 }
 
-func (s *Store) batchUpdateEntryStatus(ctx context.Context, ids []int64, updateQuery string) (err error) {
+func (s *Store) batchUpdateEntryStatus(ctx context.Context, ids []int64, updateQueries ...string) (err error) {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -107,11 +138,15 @@ func (s *Store) batchUpdateEntryStatus(ctx context.Context, ids []int64, updateQ
 	}
 	defer insStmt.Close()
 
-	updStmt, err := tx.PrepareContext(ctx, updateQuery)
-	if err != nil {
-		return err
+	updStmts := make([]*sql.Stmt, 0, len(updateQueries))
+	for _, query := range updateQueries {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		updStmts = append(updStmts, stmt)
 	}
-	defer updStmt.Close()
 
 	for _, id := range ids {
 		var exists int
@@ -124,8 +159,10 @@ func (s *Store) batchUpdateEntryStatus(ctx context.Context, ids []int64, updateQ
 		if _, err = insStmt.ExecContext(ctx, id); err != nil {
 			return err
 		}
-		if _, err = updStmt.ExecContext(ctx, id); err != nil {
-			return err
+		for _, stmt := range updStmts {
+			if _, err = stmt.ExecContext(ctx, id); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// entryIDFilter builds the WHERE clauses and ORDER BY for an
+// EntryIDListOptions query over entries e, feeds f and entry_status es.
+func (s *Store) entryIDFilter(ctx context.Context, opts EntryIDListOptions) (where []string, args []any, orderBy string, err error) {
+	status := opts.Status
+	if strings.TrimSpace(status) == "" {
+		status = "all"
+	}
+	where, _, err = statusFilter(status)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if opts.SinceID > 0 {
+		where = append(where, "e.id > ?")
+		args = append(args, opts.SinceID)
+	}
+	if opts.MaxID > 0 {
+		where = append(where, "e.id < ?")
+		args = append(args, opts.MaxID)
+	}
+	if len(opts.IDs) > 0 {
+		placeholders := make([]string, 0, len(opts.IDs))
+		for _, id := range opts.IDs {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+		where = append(where, "e.id IN ("+strings.Join(placeholders, ",")+")")
+	}
+	if opts.FeedID > 0 {
+		where = append(where, "e.feed_id = ?")
+		args = append(args, opts.FeedID)
+	}
+	if strings.TrimSpace(opts.Folder) != "" {
+		folderID, err := s.folderIDByName(ctx, opts.Folder)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		where = append(where, "f.folder_id = ?")
+		args = append(args, folderID)
+	}
+	timeWhere, timeArgs, err := timeRangeFilter(opts.Since, opts.Until)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	where = append(where, timeWhere...)
+	args = append(args, timeArgs...)
+
+	orderBy = ` ORDER BY e.id ASC`
+	if opts.Descending {
+		orderBy = ` ORDER BY e.id DESC`
+	}
+	return where, args, orderBy, nil
+}
+
+// ListEntriesByID pages through entries by ID for sync clients. A
+// non-positive limit returns 50 entries.
+func (s *Store) ListEntriesByID(ctx context.Context, opts EntryIDListOptions) ([]Entry, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+	where, args, orderBy, err := s.entryIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + entrySelectColumns + `
+		FROM entries e
+		JOIN feeds f ON f.id = e.feed_id
+		LEFT JOIN entry_status es ON es.entry_id = e.id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += orderBy + ` LIMIT ?`
+	args = append(args, opts.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ListEntryIDs returns only the IDs ListEntriesByID would select. A
+// non-positive limit returns every match.
+func (s *Store) ListEntryIDs(ctx context.Context, opts EntryIDListOptions) ([]int64, error) {
+	where, args, orderBy, err := s.entryIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT e.id
+		FROM entries e
+		JOIN feeds f ON f.id = e.feed_id
+		LEFT JOIN entry_status es ON es.entry_id = e.id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += orderBy
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkEntriesRead marks every unread entry in a feed, a folder, or (with
+// neither set) all feeds as read, and returns how many changed. Before limits
+// it to entries published or fetched at or before that time, so entries that
+// arrived after the reader last looked stay unread.
+func (s *Store) MarkEntriesRead(ctx context.Context, opts MarkReadOptions) (int64, error) {
+	if opts.FeedID > 0 && opts.FolderID > 0 {
+		return 0, fmt.Errorf("%w: mark read by feed or by folder, not both", ErrInvalidInput)
+	}
+	where := []string{"1 = 1"}
+	args := make([]any, 0, 2)
+	if opts.FeedID > 0 {
+		where = append(where, "e.feed_id = ?")
+		args = append(args, opts.FeedID)
+	}
+	if opts.FolderID > 0 {
+		where = append(where, "f.folder_id = ?")
+		args = append(args, opts.FolderID)
+	}
+	if opts.Before != nil {
+		where = append(where, entryTimeExpr+" <= julianday(?)")
+		args = append(args, timeToDBString(opts.Before))
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE entry_status SET read = 1, read_at = CURRENT_TIMESTAMP
+		WHERE read = 0 AND entry_id IN (
+			SELECT e.id FROM entries e
+			JOIN feeds f ON f.id = e.feed_id
+			WHERE `+strings.Join(where, " AND ")+`
+		)
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const maxTagLength = 64
+
+func normalizeTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if tag == "" {
+		return "", fmt.Errorf("%w: tag must not be empty", ErrInvalidInput)
+	}
+	if len(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: tag %q exceeds %d characters", ErrInvalidInput, raw, maxTagLength)
+	}
+	for _, r := range tag {
+		if r == ',' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return "", fmt.Errorf("%w: tag %q must not contain commas or whitespace", ErrInvalidInput, raw)
+		}
+	}
+	return tag, nil
+}
+
+func normalizeTags(raw []string) ([]string, error) {
+	out := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, r := range raw {
+		tag, err := normalizeTag(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	return out, nil
+}
+
+// tagFilter returns WHERE clauses requiring every tag to be present on e.
+func tagFilter(tags []string) ([]string, []any, error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	where := make([]string, 0, len(normalized))
+	args := make([]any, 0, len(normalized))
+	for _, tag := range normalized {
+		where = append(where, "EXISTS (SELECT 1 FROM entry_tags et WHERE et.entry_id = e.id AND et.tag = ?)")
+		args = append(args, tag)
+	}
+	return where, args, nil
+}
+
+func (s *Store) AddEntryTags(ctx context.Context, ids []int64, tags []string) error {
+	return s.batchUpdateEntryTags(ctx, ids, tags, `INSERT OR IGNORE INTO entry_tags(entry_id, tag) VALUES (?, ?)`)
+}
+
+func (s *Store) RemoveEntryTags(ctx context.Context, ids []int64, tags []string) error {
+	return s.batchUpdateEntryTags(ctx, ids, tags, `DELETE FROM entry_tags WHERE entry_id = ? AND tag = ?`)
+}
+
+func (s *Store) batchUpdateEntryTags(ctx context.Context, ids []int64, tags []string, query string) (err error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 || len(normalized) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		var exists int
+		if err = tx.QueryRowContext(ctx, `SELECT 1 FROM entries WHERE id = ?`, id).Scan(&exists); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		for _, tag := range normalized {
+			if _, err = stmt.ExecContext(ctx, id, tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -32,7 +34,7 @@ func TestStoreGetFetchStaleness(t *testing.T) {
 	if err := store.UpdateFeedFetchSuccess(ctx, f1.ID, "f1", "", "", "", "", now); err != nil {
 		t.Fatalf("update fetch success: %v", err)
 	}
-	_ = mustCreateFeed(t, store, "https://example.com/feed2.xml")
+	f2 := mustCreateFeed(t, store, "https://example.com/feed2.xml")
 
 	hasFeeds, stale, lastFetched, err = store.GetFetchStaleness(ctx, time.Hour)
 	if err != nil {
@@ -53,6 +55,37 @@ func TestStoreGetFetchStaleness(t *testing.T) {
 	if !stale {
 		t.Fatalf("expected stale when latest fetch is too old")
 	}
+
+	// An old last fetch alone is not enough: some unpaused feed must be due.
+	paused := true
+	if _, err := store.UpdateFeed(ctx, f2.ID, UpdateFeedInput{Paused: &paused}); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := store.SetFeedNextFetchAt(ctx, f1.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("set next fetch: %v", err)
+	}
+	if _, stale, _, err = store.GetFetchStaleness(ctx, time.Hour); err != nil || stale {
+		t.Fatalf("expected not stale when no feed is due, stale=%v err=%v", stale, err)
+	}
+	if err := store.SetFeedNextFetchAt(ctx, f1.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("set next fetch: %v", err)
+	}
+	if err := store.SetFeedRetryAfter(ctx, f1.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("set retry after: %v", err)
+	}
+	if _, stale, _, err = store.GetFetchStaleness(ctx, time.Hour); err != nil || stale {
+		t.Fatalf("expected not stale while Retry-After holds, stale=%v err=%v", stale, err)
+	}
+	interval := 24 * 60
+	if _, err := store.UpdateFeed(ctx, f1.ID, UpdateFeedInput{FetchIntervalMinutes: &interval}); err != nil {
+		t.Fatalf("set interval: %v", err)
+	}
+	if err := store.SetFeedRetryAfter(ctx, f1.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("clear retry after: %v", err)
+	}
+	if _, stale, _, err = store.GetFetchStaleness(ctx, time.Hour); err != nil || stale {
+		t.Fatalf("expected not stale inside the per-feed interval, stale=%v err=%v", stale, err)
+	}
 }
 
 func TestStoreStatusAndSearch(t *testing.T) {
@@ -239,7 +272,7 @@ func TestStoreFeedQueriesAndBatchStatus(t *testing.T) {
 		t.Fatalf("SetEntriesStarred false: %v", err)
 	}
 
-	feeds, err := s.ListFeedsWithCounts(ctx)
+	feeds, err := s.ListFeedsWithCounts(ctx, FeedListOptions{})
 	if err != nil {
 		t.Fatalf("ListFeedsWithCounts: %v", err)
 	}
@@ -247,14 +280,14 @@ func TestStoreFeedQueriesAndBatchStatus(t *testing.T) {
 		t.Fatalf("expected 2 feeds, got %d", len(feeds))
 	}
 
-	fetched, err := s.ListFeedsForFetch(ctx, &feedA.ID)
+	fetched, err := s.ListFeedsForFetch(ctx, FetchOptions{FeedID: &feedA.ID})
 	if err != nil {
 		t.Fatalf("ListFeedsForFetch by id: %v", err)
 	}
 	if len(fetched) != 1 || fetched[0].ID != feedA.ID {
 		t.Fatalf("unexpected fetch list: %#v", fetched)
 	}
-	allFetch, err := s.ListFeedsForFetch(ctx, nil)
+	allFetch, err := s.ListFeedsForFetch(ctx, FetchOptions{})
 	if err != nil {
 		t.Fatalf("ListFeedsForFetch all: %v", err)
 	}
@@ -262,7 +295,7 @@ func TestStoreFeedQueriesAndBatchStatus(t *testing.T) {
 		t.Fatalf("expected 2 feeds for fetch, got %d", len(allFetch))
 	}
 	missingID := int64(999)
-	if _, err := s.ListFeedsForFetch(ctx, &missingID); !errors.Is(err, ErrNotFound) {
+	if _, err := s.ListFeedsForFetch(ctx, FetchOptions{FeedID: &missingID}); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("ListFeedsForFetch missing err=%v, want ErrNotFound", err)
 	}
 
@@ -300,3 +333,900 @@ func TestStoreFeedQueriesAndBatchStatus(t *testing.T) {
 		t.Fatalf("expected 2 feed urls, got %d", len(feedURLs))
 	}
 }
+
+func TestStoreFoldersFilterFeedsAndEntries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	tech := mustCreateFeed(t, s, "https://example.com/tech.xml")
+	news := mustCreateFeed(t, s, "https://example.com/news.xml")
+	if err := s.SetFeedFolder(ctx, tech.ID, "Tech"); err != nil {
+		t.Fatalf("SetFeedFolder tech: %v", err)
+	}
+	if err := s.SetFeedFolder(ctx, news.ID, "News"); err != nil {
+		t.Fatalf("SetFeedFolder news: %v", err)
+	}
+	if _, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: tech.ID, GUID: "t1", Title: "T1"}); err != nil {
+		t.Fatalf("upsert t1: %v", err)
+	}
+	if _, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: news.ID, GUID: "n1", Title: "N1"}); err != nil {
+		t.Fatalf("upsert n1: %v", err)
+	}
+
+	got, err := s.GetFeedByID(ctx, tech.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID: %v", err)
+	}
+	if got.Folder != "Tech" || got.FolderID == 0 {
+		t.Fatalf("expected feed in folder Tech, got %+v", got)
+	}
+
+	feeds, err := s.ListFeedsWithCounts(ctx, FeedListOptions{Folder: "tech"})
+	if err != nil {
+		t.Fatalf("ListFeedsWithCounts folder: %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].ID != tech.ID || feeds[0].TotalCount != 1 {
+		t.Fatalf("unexpected folder feeds: %#v", feeds)
+	}
+
+	entries, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Folder: "News", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListEntries folder: %v", err)
+	}
+	if len(entries) != 1 || entries[0].FeedID != news.ID {
+		t.Fatalf("unexpected folder entries: %#v", entries)
+	}
+
+	fetchFeeds, err := s.ListFeedsForFetch(ctx, FetchOptions{Folder: "Tech"})
+	if err != nil {
+		t.Fatalf("ListFeedsForFetch folder: %v", err)
+	}
+	if len(fetchFeeds) != 1 || fetchFeeds[0].ID != tech.ID {
+		t.Fatalf("unexpected fetch feeds: %#v", fetchFeeds)
+	}
+
+	if _, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Folder: "Missing"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ListEntries missing folder err=%v, want ErrNotFound", err)
+	}
+
+	if err := s.SetFeedFolder(ctx, tech.ID, ""); err != nil {
+		t.Fatalf("SetFeedFolder clear: %v", err)
+	}
+	got, err = s.GetFeedByID(ctx, tech.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID after clear: %v", err)
+	}
+	if got.Folder != "" || got.FolderID != 0 {
+		t.Fatalf("expected folder to be cleared, got %+v", got)
+	}
+
+	folders, err := s.ListFolders(ctx)
+	if err != nil {
+		t.Fatalf("ListFolders: %v", err)
+	}
+	if len(folders) != 2 {
+		t.Fatalf("expected 2 folders, got %#v", folders)
+	}
+}
+
+func TestStoreEntryTagsFilterListAndSearch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/tags.xml")
+
+	id1, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: "t1", Title: "Kernel exploit writeup"})
+	if err != nil {
+		t.Fatalf("upsert 1: %v", err)
+	}
+	id2, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: "t2", Title: "Kernel scheduler notes"})
+	if err != nil {
+		t.Fatalf("upsert 2: %v", err)
+	}
+
+	if err := s.AddEntryTags(ctx, []int64{id1, id2}, []string{"To-Read"}); err != nil {
+		t.Fatalf("AddEntryTags to-read: %v", err)
+	}
+	if err := s.AddEntryTags(ctx, []int64{id1}, []string{"security"}); err != nil {
+		t.Fatalf("AddEntryTags security: %v", err)
+	}
+
+	got, err := s.GetEntry(ctx, id1)
+	if err != nil {
+		t.Fatalf("GetEntry: %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "security" || got.Tags[1] != "to-read" {
+		t.Fatalf("unexpected tags: %#v", got.Tags)
+	}
+
+	tagged, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Tags: []string{"to-read", "security"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListEntries tags: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != id1 {
+		t.Fatalf("expected only entry 1 to match both tags, got %#v", tagged)
+	}
+
+	results, err := s.SearchEntries(ctx, SearchOptions{Query: "kernel", Tags: []string{"security"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchEntries tag: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != id1 {
+		t.Fatalf("expected tagged search hit, got %#v", results)
+	}
+
+	if err := s.RemoveEntryTags(ctx, []int64{id1}, []string{"security"}); err != nil {
+		t.Fatalf("RemoveEntryTags: %v", err)
+	}
+	results, err = s.SearchEntries(ctx, SearchOptions{Query: "kernel", Tags: []string{"security"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchEntries after untag: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no results after untag, got %#v", results)
+	}
+
+	if err := s.AddEntryTags(ctx, []int64{id1}, []string{"has space"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("AddEntryTags invalid err=%v, want ErrInvalidInput", err)
+	}
+	if err := s.AddEntryTags(ctx, []int64{999}, []string{"x"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("AddEntryTags missing err=%v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreListEntriesStarredStatus(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/starred.xml")
+
+	ids := make([]int64, 0, 3)
+	for _, guid := range []string{"s1", "s2", "s3"} {
+		id, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: guid, Title: guid})
+		if err != nil {
+			t.Fatalf("upsert %s: %v", guid, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := s.SetEntriesStarred(ctx, []int64{ids[0], ids[2]}, true); err != nil {
+		t.Fatalf("SetEntriesStarred: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE entry_status SET starred_at = '2026-01-01T00:00:00Z' WHERE entry_id = ?`, ids[2]); err != nil {
+		t.Fatalf("backdate starred_at: %v", err)
+	}
+	if err := s.SetEntriesRead(ctx, []int64{ids[0]}, true); err != nil {
+		t.Fatalf("SetEntriesRead: %v", err)
+	}
+
+	starred, err := s.ListEntries(ctx, EntryListOptions{Status: "starred", Limit: 10})
+	if err != nil {
+		t.Fatalf("list starred: %v", err)
+	}
+	if len(starred) != 2 || starred[0].ID != ids[0] || starred[1].ID != ids[2] {
+		t.Fatalf("expected starred entries ordered by starred_at desc, got %#v", starred)
+	}
+
+	unreadStarred, err := s.ListEntries(ctx, EntryListOptions{Status: "unread+starred", Limit: 10})
+	if err != nil {
+		t.Fatalf("list unread+starred: %v", err)
+	}
+	if len(unreadStarred) != 1 || unreadStarred[0].ID != ids[2] {
+		t.Fatalf("expected only unread starred entry, got %#v", unreadStarred)
+	}
+
+	if _, err := s.ListEntries(ctx, EntryListOptions{Status: "read+unread"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("ListEntries read+unread err=%v, want ErrInvalidInput", err)
+	}
+}
+
+func TestStoreEntriesTimeRangeFilter(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/range.xml")
+
+	oldID, _, err := s.UpsertEntry(ctx, UpsertEntryInput{
+		FeedID:      feed.ID,
+		GUID:        "old",
+		Title:       "range old",
+		PublishedAt: ptrTime(time.Now().Add(-72 * time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("upsert old: %v", err)
+	}
+	recentID, _, err := s.UpsertEntry(ctx, UpsertEntryInput{
+		FeedID:      feed.ID,
+		GUID:        "recent",
+		Title:       "range recent",
+		PublishedAt: ptrTime(time.Now().Add(-2 * time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("upsert recent: %v", err)
+	}
+	undatedID, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: "undated", Title: "range undated"})
+	if err != nil {
+		t.Fatalf("upsert undated: %v", err)
+	}
+
+	since := time.Now().Add(-48 * time.Hour)
+	recent, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Since: &since, Limit: 10})
+	if err != nil {
+		t.Fatalf("list since: %v", err)
+	}
+	got := map[int64]bool{}
+	for _, e := range recent {
+		got[e.ID] = true
+	}
+	if len(recent) != 2 || !got[recentID] || !got[undatedID] {
+		t.Fatalf("expected recent and fetched-at fallback entries, got %#v", recent)
+	}
+
+	until := time.Now().Add(-24 * time.Hour)
+	older, err := s.SearchEntries(ctx, SearchOptions{Query: "range", Until: &until, Limit: 10})
+	if err != nil {
+		t.Fatalf("search until: %v", err)
+	}
+	if len(older) != 1 || older[0].ID != oldID {
+		t.Fatalf("expected only old entry before cutoff, got %#v", older)
+	}
+
+	if _, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Since: &until, Until: &since}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("inverted range err=%v, want ErrInvalidInput", err)
+	}
+}
+
+func TestStoreCursorPaginationWalksAllEntries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/pages.xml")
+
+	base := time.Now().Add(-time.Hour)
+	want := make(map[int64]bool)
+	ids := make([]int64, 0, 7)
+	for i := 0; i < 7; i++ {
+		in := UpsertEntryInput{FeedID: feed.ID, GUID: fmt.Sprintf("p%d", i), Title: fmt.Sprintf("paged post %d", i)}
+		// Two entries share a timestamp and two are undated to exercise tie-breaks.
+		if i < 5 {
+			in.PublishedAt = ptrTime(base.Add(time.Duration(i/2) * time.Minute))
+		}
+		id, _, err := s.UpsertEntry(ctx, in)
+		if err != nil {
+			t.Fatalf("upsert %d: %v", i, err)
+		}
+		want[id] = true
+		ids = append(ids, id)
+	}
+	if err := s.SetEntriesStarred(ctx, ids[:3], true); err != nil {
+		t.Fatalf("star: %v", err)
+	}
+
+	walk := func(name string, page func(after string) ([]Entry, error)) {
+		t.Helper()
+		seen := make(map[int64]bool)
+		after := ""
+		for i := 0; i < 10; i++ {
+			entries, err := page(after)
+			if err != nil {
+				t.Fatalf("%s page %d: %v", name, i, err)
+			}
+			if len(entries) == 0 {
+				break
+			}
+			for _, e := range entries {
+				if seen[e.ID] {
+					t.Fatalf("%s: entry %d returned twice", name, e.ID)
+				}
+				seen[e.ID] = true
+			}
+			after = entries[len(entries)-1].Cursor
+		}
+		for id := range want {
+			if !seen[id] && name != "starred" {
+				t.Fatalf("%s: entry %d never returned", name, id)
+			}
+		}
+		if name == "starred" && len(seen) != 3 {
+			t.Fatalf("starred: expected 3 entries, got %d", len(seen))
+		}
+	}
+
+	walk("published", func(after string) ([]Entry, error) {
+		return s.ListEntries(ctx, EntryListOptions{Status: "all", After: after, Limit: 2})
+	})
+	walk("starred", func(after string) ([]Entry, error) {
+		return s.ListEntries(ctx, EntryListOptions{Status: "starred", After: after, Limit: 2})
+	})
+	walk("search", func(after string) ([]Entry, error) {
+		return s.SearchEntries(ctx, SearchOptions{Query: "paged", After: after, Limit: 2})
+	})
+
+	first, err := s.SearchEntries(ctx, SearchOptions{Query: "paged", Limit: 1})
+	if err != nil {
+		t.Fatalf("search first: %v", err)
+	}
+	if _, err := s.ListEntries(ctx, EntryListOptions{Status: "all", After: first[0].Cursor}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("cross-ordering cursor err=%v, want ErrInvalidInput", err)
+	}
+	if _, err := s.ListEntries(ctx, EntryListOptions{Status: "all", After: "not-a-cursor"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("malformed cursor err=%v, want ErrInvalidInput", err)
+	}
+}
+
+func TestStoreUpdateFeedSettings(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feedA := mustCreateFeed(t, s, "https://example.com/settings-a.xml")
+	feedB := mustCreateFeed(t, s, "https://example.com/settings-b.xml")
+
+	title := "My Title"
+	paused := true
+	interval := 360
+	updated, err := s.UpdateFeed(ctx, feedA.ID, UpdateFeedInput{CustomTitle: &title, Paused: &paused, FetchIntervalMinutes: &interval})
+	if err != nil {
+		t.Fatalf("UpdateFeed: %v", err)
+	}
+	if updated.Title != "My Title" || updated.CustomTitle != "My Title" || !updated.Paused || updated.FetchIntervalMinutes != 360 {
+		t.Fatalf("unexpected updated feed: %+v", updated)
+	}
+
+	if err := s.UpdateFeedFetchSuccess(ctx, feedA.ID, "Feed Title", "", "", "", "", time.Now()); err != nil {
+		t.Fatalf("UpdateFeedFetchSuccess: %v", err)
+	}
+	got, err := s.GetFeedByID(ctx, feedA.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID: %v", err)
+	}
+	if got.Title != "My Title" {
+		t.Fatalf("custom title should survive fetch, got %q", got.Title)
+	}
+
+	if _, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feedA.ID, GUID: "a1"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	entries, err := s.ListEntries(ctx, EntryListOptions{Status: "all"})
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].FeedTitle != "My Title" {
+		t.Fatalf("expected entries to use custom feed title, got %#v", entries)
+	}
+
+	due, err := s.ListFeedsForFetch(ctx, FetchOptions{})
+	if err != nil {
+		t.Fatalf("ListFeedsForFetch: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != feedB.ID {
+		t.Fatalf("expected paused feed to be skipped, got %#v", due)
+	}
+	explicit, err := s.ListFeedsForFetch(ctx, FetchOptions{FeedID: &feedA.ID})
+	if err != nil || len(explicit) != 1 {
+		t.Fatalf("expected paused feed when requested by id, got %#v err=%v", explicit, err)
+	}
+
+	empty := ""
+	got, err = s.UpdateFeed(ctx, feedA.ID, UpdateFeedInput{CustomTitle: &empty})
+	if err != nil {
+		t.Fatalf("clear title: %v", err)
+	}
+	if got.Title != "Feed Title" || got.CustomTitle != "" {
+		t.Fatalf("expected feed title after clearing custom title, got %+v", got)
+	}
+
+	if _, err := s.UpdateFeed(ctx, 999, UpdateFeedInput{Paused: &paused}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateFeed missing err=%v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreUpdateFeedURLKeepsEntries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://old.example.com/feed.xml")
+	other := mustCreateFeed(t, s, "https://other.example.com/feed.xml")
+
+	entryID, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: "kept", Title: "kept"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := s.SetEntriesStarred(ctx, []int64{entryID}, true); err != nil {
+		t.Fatalf("star: %v", err)
+	}
+	if err := s.UpdateFeedFetchSuccess(ctx, feed.ID, "Old", "", "", `"etag"`, "Mon, 02 Jan 2006 15:04:05 GMT", time.Now()); err != nil {
+		t.Fatalf("fetch success: %v", err)
+	}
+
+	if err := s.UpdateFeedURL(ctx, feed.ID, "https://new.example.com/feed.xml"); err != nil {
+		t.Fatalf("UpdateFeedURL: %v", err)
+	}
+	got, err := s.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID: %v", err)
+	}
+	if got.URL != "https://new.example.com/feed.xml" || got.ETag != "" || got.LastModified != "" {
+		t.Fatalf("expected url updated and cache headers reset, got %+v", got)
+	}
+	entry, err := s.GetEntry(ctx, entryID)
+	if err != nil {
+		t.Fatalf("GetEntry: %v", err)
+	}
+	if entry.FeedID != feed.ID || !entry.Starred {
+		t.Fatalf("expected entry and starred state to be kept, got %+v", entry)
+	}
+
+	if err := s.UpdateFeedURL(ctx, feed.ID, other.URL); !errors.Is(err, ErrConflict) {
+		t.Fatalf("UpdateFeedURL duplicate err=%v, want ErrConflict", err)
+	}
+	if err := s.UpdateFeedURL(ctx, 999, "https://missing.example.com/feed.xml"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateFeedURL missing err=%v, want ErrNotFound", err)
+	}
+
+	title, paused := "Renamed", true
+	if _, err := s.UpdateFeed(ctx, feed.ID, UpdateFeedInput{URL: &other.URL, CustomTitle: &title, Paused: &paused}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("UpdateFeed duplicate err=%v, want ErrConflict", err)
+	}
+	got, err = s.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID: %v", err)
+	}
+	if got.URL != "https://new.example.com/feed.xml" || got.CustomTitle != "" || got.Paused {
+		t.Fatalf("expected a failed update to change nothing, got %+v", got)
+	}
+}
+
+func TestStoreUpdateFeedURLClearsRetryAfter(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://old.example.com/feed.xml")
+	if err := s.SetFeedRetryAfter(ctx, feed.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetFeedRetryAfter: %v", err)
+	}
+	if err := s.SetFeedNextFetchAt(ctx, feed.ID, time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatalf("SetFeedNextFetchAt: %v", err)
+	}
+
+	if err := s.UpdateFeedURL(ctx, feed.ID, "https://new.example.com/feed.xml"); err != nil {
+		t.Fatalf("UpdateFeedURL: %v", err)
+	}
+	got, err := s.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("GetFeedByID: %v", err)
+	}
+	if got.RetryAfter != nil || got.NextFetchAt != nil {
+		t.Fatalf("expected the new URL to be due and fetchable, got retry_after=%v next_fetch_at=%v", got.RetryAfter, got.NextFetchAt)
+	}
+}
+
+func TestStoreFeedPostingInterval(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+
+	gap, err := s.FeedPostingInterval(ctx, feed.ID)
+	if err != nil || gap != 0 {
+		t.Fatalf("FeedPostingInterval empty = %s, %v", gap, err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		published := base.Add(time.Duration(i) * 6 * time.Hour)
+		if _, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: fmt.Sprintf("g%d", i), Title: "t", PublishedAt: &published}); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	gap, err = s.FeedPostingInterval(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("FeedPostingInterval: %v", err)
+	}
+	if gap < 6*time.Hour-time.Second || gap > 6*time.Hour+time.Second {
+		t.Fatalf("FeedPostingInterval = %s, want 6h", gap)
+	}
+}
+
+func TestStoreUpsertEntriesSkipsUnchanged(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+
+	batch := []UpsertEntryInput{
+		{FeedID: feed.ID, GUID: "a", Title: "Alpha", ContentMD: "first"},
+		{FeedID: feed.ID, GUID: "b", Title: "Beta", ContentMD: "second"},
+		{FeedID: feed.ID, GUID: "c", Title: "Gamma", ContentMD: "third"},
+	}
+	inserted, updated, err := s.UpsertEntries(ctx, batch)
+	if err != nil || inserted != 3 || updated != 0 {
+		t.Fatalf("first UpsertEntries = %d inserted, %d updated, err=%v", inserted, updated, err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE entries SET fetched_at = '2000-01-01 00:00:00'`); err != nil {
+		t.Fatalf("backdate fetched_at: %v", err)
+	}
+
+	inserted, updated, err = s.UpsertEntries(ctx, batch)
+	if err != nil || inserted != 0 || updated != 0 {
+		t.Fatalf("repeat UpsertEntries = %d inserted, %d updated, err=%v", inserted, updated, err)
+	}
+	var untouched int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM entries WHERE fetched_at = '2000-01-01 00:00:00'`).Scan(&untouched); err != nil {
+		t.Fatalf("count untouched: %v", err)
+	}
+	if untouched != 3 {
+		t.Fatalf("expected unchanged entries to keep fetched_at, %d of 3 did", untouched)
+	}
+
+	batch[1].ContentMD = "second, edited"
+	inserted, updated, err = s.UpsertEntries(ctx, batch)
+	if err != nil || inserted != 0 || updated != 1 {
+		t.Fatalf("edited UpsertEntries = %d inserted, %d updated, err=%v", inserted, updated, err)
+	}
+	results, err := s.SearchEntries(ctx, SearchOptions{Query: "edited", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].GUID != "b" {
+		t.Fatalf("expected FTS to reflect the edit, got %+v", results)
+	}
+}
+
+func TestStoreUpsertEntriesBackfillsContentHash(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+
+	in := UpsertEntryInput{FeedID: feed.ID, GUID: "legacy", Title: "Legacy", ContentMD: "body"}
+	if _, _, err := s.UpsertEntries(ctx, []UpsertEntryInput{in}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE entries SET content_hash = NULL`); err != nil {
+		t.Fatalf("clear hash: %v", err)
+	}
+
+	inserted, updated, err := s.UpsertEntries(ctx, []UpsertEntryInput{in})
+	if err != nil || inserted != 0 || updated != 0 {
+		t.Fatalf("legacy UpsertEntries = %d inserted, %d updated, err=%v", inserted, updated, err)
+	}
+	var hash sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT content_hash FROM entries WHERE guid = 'legacy'`).Scan(&hash); err != nil {
+		t.Fatalf("read hash: %v", err)
+	}
+	if hash.String != entryContentHash(in) {
+		t.Fatalf("expected content hash to be backfilled, got %q", hash.String)
+	}
+
+	in.Title = "Legacy, retitled"
+	if _, updated, err = s.UpsertEntries(ctx, []UpsertEntryInput{in}); err != nil || updated != 1 {
+		t.Fatalf("changed UpsertEntries updated=%d err=%v", updated, err)
+	}
+}
+
+func TestStoreEntryRevisions(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+
+	in := UpsertEntryInput{FeedID: feed.ID, GUID: "post", URL: "https://example.com/post", Title: "Post", ContentMD: "draft"}
+	id, _, err := s.UpsertEntry(ctx, in)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	// A metadata-only change is not a new revision.
+	in.URL = "https://example.com/post?utm=1"
+	if _, _, err := s.UpsertEntry(ctx, in); err != nil {
+		t.Fatalf("update url: %v", err)
+	}
+	in.ContentMD = "final"
+	if _, _, err := s.UpsertEntry(ctx, in); err != nil {
+		t.Fatalf("update content: %v", err)
+	}
+
+	revs, err := s.ListEntryRevisions(ctx, id)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("expected 2 revisions, got %+v", revs)
+	}
+	if revs[0].Revision != 1 || revs[0].ContentMD != "draft" || revs[0].Current {
+		t.Fatalf("unexpected first revision: %+v", revs[0])
+	}
+	if revs[1].Revision != 2 || revs[1].ContentMD != "final" || !revs[1].Current {
+		t.Fatalf("unexpected current revision: %+v", revs[1])
+	}
+
+	if _, err := s.ListEntryRevisions(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ListEntryRevisions missing err=%v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreEnclosures(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/podcast.xml")
+
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	batch := []UpsertEntryInput{
+		{FeedID: feed.ID, GUID: "ep1", Title: "Episode 1", PublishedAt: &older, Enclosures: []Enclosure{
+			{URL: "https://cdn.example.com/ep1.mp3", MimeType: "audio/mpeg", Length: 1234, DurationSeconds: 600},
+		}},
+		{FeedID: feed.ID, GUID: "ep2", Title: "Episode 2", PublishedAt: &newer, Enclosures: []Enclosure{
+			{URL: "https://cdn.example.com/ep2.m4a", MimeType: "audio/mp4"},
+			{URL: "https://cdn.example.com/ep2.mp4", MimeType: "video/mp4"},
+		}},
+		{FeedID: feed.ID, GUID: "post", Title: "Show notes"},
+	}
+	if _, _, err := s.UpsertEntries(ctx, batch); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	audio, err := s.ListEnclosures(ctx, EnclosureListOptions{MimeType: "audio"})
+	if err != nil {
+		t.Fatalf("list audio: %v", err)
+	}
+	if len(audio) != 2 || audio[0].URL != "https://cdn.example.com/ep2.m4a" || audio[1].DurationSeconds != 600 {
+		t.Fatalf("unexpected audio enclosures: %+v", audio)
+	}
+	if audio[1].EntryTitle != "Episode 1" || audio[1].Length != 1234 {
+		t.Fatalf("expected entry details on enclosure, got %+v", audio[1])
+	}
+	exact, err := s.ListEnclosures(ctx, EnclosureListOptions{MimeType: "video/mp4"})
+	if err != nil || len(exact) != 1 {
+		t.Fatalf("list video/mp4 = %+v, err=%v", exact, err)
+	}
+	if _, err := s.ListEnclosures(ctx, EnclosureListOptions{MimeType: "audio/"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected invalid input for malformed MIME type, got %v", err)
+	}
+
+	entries, err := s.ListEntries(ctx, EntryListOptions{Status: "all", Limit: 10})
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	for _, e := range entries {
+		switch e.GUID {
+		case "ep2":
+			if len(e.Enclosures) != 2 || e.Enclosures[0].MimeType != "audio/mp4" || e.Enclosures[1].MimeType != "video/mp4" {
+				t.Fatalf("expected enclosures in feed order, got %+v", e.Enclosures)
+			}
+		case "post":
+			if e.Enclosures != nil {
+				t.Fatalf("expected no enclosures, got %+v", e.Enclosures)
+			}
+		}
+	}
+
+	// Enclosures follow their position, not the order rows were written in.
+	if _, err := s.db.ExecContext(ctx, `UPDATE enclosures SET position = -position WHERE url LIKE '%/ep2.%'`); err != nil {
+		t.Fatalf("reorder enclosures: %v", err)
+	}
+	entries, err = s.ListEntries(ctx, EntryListOptions{Status: "all", Limit: 10})
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	for _, e := range entries {
+		if e.GUID == "ep2" && (len(e.Enclosures) != 2 || e.Enclosures[0].MimeType != "video/mp4") {
+			t.Fatalf("expected enclosures in position order, got %+v", e.Enclosures)
+		}
+	}
+
+	// A replaced enclosure alone counts as an update.
+	batch[0].Enclosures[0].URL = "https://cdn.example.com/ep1-fixed.mp3"
+	inserted, updated, err := s.UpsertEntries(ctx, batch)
+	if err != nil || inserted != 0 || updated != 1 {
+		t.Fatalf("UpsertEntries = %d inserted, %d updated, err=%v", inserted, updated, err)
+	}
+	audio, err = s.ListEnclosures(ctx, EnclosureListOptions{MimeType: "audio/mpeg"})
+	if err != nil || len(audio) != 1 || audio[0].URL != "https://cdn.example.com/ep1-fixed.mp3" {
+		t.Fatalf("expected replaced enclosure, got %+v, err=%v", audio, err)
+	}
+}
+
+func TestStoreSyncQueries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	tech := mustCreateFeed(t, s, "https://example.com/tech.xml")
+	news := mustCreateFeed(t, s, "https://example.com/news.xml")
+	if err := s.SetFeedFolder(ctx, tech.ID, "Tech"); err != nil {
+		t.Fatalf("set folder: %v", err)
+	}
+	folders, err := s.ListFolders(ctx)
+	if err != nil || len(folders) != 1 {
+		t.Fatalf("list folders = %+v, err=%v", folders, err)
+	}
+
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	batch := []UpsertEntryInput{
+		{FeedID: tech.ID, GUID: "t1", Title: "Tech Jan", PublishedAt: &jan},
+		{FeedID: tech.ID, GUID: "t2", Title: "Tech Feb", PublishedAt: &feb},
+		{FeedID: news.ID, GUID: "n1", Title: "News Jan", PublishedAt: &jan},
+	}
+	if _, _, err := s.UpsertEntries(ctx, batch); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	all, err := s.ListEntriesByID(ctx, EntryIDListOptions{})
+	if err != nil || len(all) != 3 || all[0].ID > all[2].ID {
+		t.Fatalf("list by id = %+v, err=%v", all, err)
+	}
+	since, err := s.ListEntriesByID(ctx, EntryIDListOptions{SinceID: all[0].ID, Limit: 1})
+	if err != nil || len(since) != 1 || since[0].ID != all[1].ID {
+		t.Fatalf("since_id page = %+v, err=%v", since, err)
+	}
+	before, err := s.ListEntriesByID(ctx, EntryIDListOptions{MaxID: all[2].ID, Descending: true})
+	if err != nil || len(before) != 2 || before[0].ID != all[1].ID {
+		t.Fatalf("max_id page = %+v, err=%v", before, err)
+	}
+	picked, err := s.ListEntriesByID(ctx, EntryIDListOptions{IDs: []int64{all[2].ID}})
+	if err != nil || len(picked) != 1 || picked[0].Title != "News Jan" {
+		t.Fatalf("with ids = %+v, err=%v", picked, err)
+	}
+	techIDs, err := s.ListEntryIDs(ctx, EntryIDListOptions{Folder: "Tech", Since: &feb, Descending: true})
+	if err != nil || len(techIDs) != 1 || techIDs[0] != all[1].ID {
+		t.Fatalf("folder ids since feb = %v, err=%v", techIDs, err)
+	}
+
+	cutoff := jan.Add(time.Hour)
+	n, err := s.MarkEntriesRead(ctx, MarkReadOptions{FolderID: folders[0].ID, Before: &cutoff})
+	if err != nil || n != 1 {
+		t.Fatalf("mark folder read = %d, err=%v", n, err)
+	}
+	unread, err := s.ListEntryIDs(ctx, EntryIDListOptions{Status: "unread"})
+	if err != nil || len(unread) != 2 || unread[0] != all[1].ID || unread[1] != all[2].ID {
+		t.Fatalf("unread ids after folder mark = %v, err=%v", unread, err)
+	}
+	if n, err := s.MarkEntriesRead(ctx, MarkReadOptions{FeedID: news.ID}); err != nil || n != 1 {
+		t.Fatalf("mark feed read = %d, err=%v", n, err)
+	}
+	if n, err := s.MarkEntriesRead(ctx, MarkReadOptions{}); err != nil || n != 1 {
+		t.Fatalf("mark all read = %d, err=%v", n, err)
+	}
+	if _, err := s.MarkEntriesRead(ctx, MarkReadOptions{FeedID: news.ID, FolderID: folders[0].ID}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected invalid input for feed and folder, got %v", err)
+	}
+}
+
+func TestStoreFullContentSearchAndInvalidation(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, store, "https://example.com/full.xml")
+
+	in := UpsertEntryInput{FeedID: feed.ID, GUID: "g1", URL: "https://example.com/g1", Title: "Teaser", ContentHTML: "<p>short</p>", ContentMD: "short"}
+	id, _, err := store.UpsertEntry(ctx, in)
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.SetEntryFullContent(ctx, id, "<p>borrow checker</p>", "borrow checker", time.Now()); err != nil {
+		t.Fatalf("set full content: %v", err)
+	}
+	results, err := store.SearchEntries(ctx, SearchOptions{Query: "borrow", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != id {
+		t.Fatalf("expected the full article text to be searchable, got %+v", results)
+	}
+
+	// A new title alone keeps the article; a new body invalidates it.
+	in.Title = "Renamed"
+	if _, _, err := store.UpsertEntry(ctx, in); err != nil {
+		t.Fatalf("upsert renamed: %v", err)
+	}
+	if missing, err := store.ListEntriesMissingFullContent(ctx, feed.ID, 10); err != nil || len(missing) != 0 {
+		t.Fatalf("expected the article to survive a title change, got %+v, %v", missing, err)
+	}
+	in.ContentHTML, in.ContentMD = "<p>longer</p>", "longer"
+	if _, _, err := store.UpsertEntry(ctx, in); err != nil {
+		t.Fatalf("upsert changed: %v", err)
+	}
+	missing, err := store.ListEntriesMissingFullContent(ctx, feed.ID, 10)
+	if err != nil {
+		t.Fatalf("list missing: %v", err)
+	}
+	if len(missing) != 1 || missing[0].ID != id {
+		t.Fatalf("expected a changed body to queue the article for extraction, got %+v", missing)
+	}
+}
+
+func TestStoreUpsertSkipsRevisionsForReprocessedItems(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+
+	in := UpsertEntryInput{FeedID: feed.ID, GUID: "post", Title: "Post", ContentHTML: "<p>v1</p>", ContentMD: "v1", SourceHash: "src1"}
+	id, _, err := s.UpsertEntry(ctx, in)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	// Same source item, different processing output.
+	in.ContentHTML = `<p class="x">v1</p>`
+	if _, updated, err := s.UpsertEntries(ctx, []UpsertEntryInput{in}); err != nil || updated != 0 {
+		t.Fatalf("reprocessed upsert: updated=%d err=%v, want 0", updated, err)
+	}
+	// A changed source item is archived as before.
+	in.ContentHTML, in.ContentMD, in.SourceHash = "<p>v2</p>", "v2", "src2"
+	if _, updated, err := s.UpsertEntries(ctx, []UpsertEntryInput{in}); err != nil || updated != 1 {
+		t.Fatalf("edited upsert: updated=%d err=%v, want 1", updated, err)
+	}
+
+	revs, err := s.ListEntryRevisions(ctx, id)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions: %v", err)
+	}
+	if len(revs) != 2 || revs[0].ContentHTML != `<p class="x">v1</p>` || revs[1].ContentMD != "v2" {
+		t.Fatalf("expected one archived revision holding the reprocessed content, got %+v", revs)
+	}
+}
+
+func TestStoreUpsertLegacyRowsWithoutSourceHash(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Rows as an older release stored them: no source hash.
+	legacy := func(guid string) UpsertEntryInput {
+		in := UpsertEntryInput{FeedID: feed.ID, GUID: guid, URL: "https://example.com/" + guid, Title: "Post", Author: "Ann", PublishedAt: &published, ContentHTML: "<p>v1</p>", ContentMD: "v1"}
+		if _, _, err := s.UpsertEntry(ctx, in); err != nil {
+			t.Fatalf("insert %s: %v", guid, err)
+		}
+		in.SourceHash = "src-" + guid
+		return in
+	}
+
+	// Only the rendering differs: refreshed quietly.
+	rerendered := legacy("rerendered")
+	rerendered.ContentHTML = `<p class="x">v1</p>`
+	if _, updated, err := s.UpsertEntries(ctx, []UpsertEntryInput{rerendered}); err != nil || updated != 0 {
+		t.Fatalf("re-rendered legacy upsert: updated=%d err=%v, want 0", updated, err)
+	}
+
+	// The feed actually edited the item: archived and counted.
+	edited := legacy("edited")
+	edited.Title, edited.ContentHTML, edited.ContentMD = "Post (updated)", "<p>v2</p>", "v2"
+	if _, updated, err := s.UpsertEntries(ctx, []UpsertEntryInput{edited}); err != nil || updated != 1 {
+		t.Fatalf("edited legacy upsert: updated=%d err=%v, want 1", updated, err)
+	}
+
+	for guid, want := range map[string]int{"rerendered": 1, "edited": 2} {
+		var id int64
+		if err := s.db.QueryRowContext(ctx, `SELECT id FROM entries WHERE guid = ?`, guid).Scan(&id); err != nil {
+			t.Fatalf("lookup %s: %v", guid, err)
+		}
+		revs, err := s.ListEntryRevisions(ctx, id)
+		if err != nil {
+			t.Fatalf("ListEntryRevisions %s: %v", guid, err)
+		}
+		if len(revs) != want {
+			t.Fatalf("%s: got %d revisions, want %d: %+v", guid, len(revs), want, revs)
+		}
+	}
+}
+
+func TestStoreUpdateEntries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	feed := mustCreateFeed(t, s, "https://example.com/feed.xml")
+	id, _, err := s.UpsertEntry(ctx, UpsertEntryInput{FeedID: feed.ID, GUID: "g1", Title: "One"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	yes := true
+
+	for _, in := range []UpdateEntriesInput{
+		{Read: &yes},
+		{IDs: []int64{id, 0}, Read: &yes},
+		{IDs: []int64{id}},
+	} {
+		if err := s.UpdateEntries(ctx, in); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("UpdateEntries(%+v) err=%v, want ErrInvalidInput", in, err)
+		}
+	}
+	// An unknown ID rolls back the whole change.
+	if err := s.UpdateEntries(ctx, UpdateEntriesInput{IDs: []int64{id, 999}, Read: &yes}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateEntries unknown id err=%v, want ErrNotFound", err)
+	}
+	if e, err := s.GetEntry(ctx, id); err != nil || e.Read {
+		t.Fatalf("expected entry to stay unread, got read=%v err=%v", e.Read, err)
+	}
+
+	if err := s.UpdateEntries(ctx, UpdateEntriesInput{IDs: []int64{id}, Read: &yes, Starred: &yes}); err != nil {
+		t.Fatalf("UpdateEntries: %v", err)
+	}
+	if e, err := s.GetEntry(ctx, id); err != nil || !e.Read || !e.Starred {
+		t.Fatalf("expected entry read and starred, got %+v, %v", e, err)
+	}
+}
@@ -0,0 +1,376 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type upsertOutcome int
+
+const (
+	upsertUnchanged upsertOutcome = iota
+	upsertInserted
+	upsertUpdated
+)
+
+// entryUpserter holds the prepared statements used to write feed items
+// inside a single transaction.
+type entryUpserter struct {
+	lookup         *sql.Stmt
+	insert         *sql.Stmt
+	update         *sql.Stmt
+	archive        *sql.Stmt
+	rehash         *sql.Stmt
+	sameMetadata   *sql.Stmt
+	status         *sql.Stmt
+	listEnclosures *sql.Stmt
+	clearEnclosure *sql.Stmt
+	addEnclosure   *sql.Stmt
+}
+
+// entryContentHash fingerprints every stored field of an entry so a fetch
+// can tell whether an item changed without comparing columns one by one.
+func entryContentHash(in UpsertEntryInput) string {
+	h := sha256.New()
+	for _, v := range []any{
+		in.URL,
+		in.ExternalURL,
+		in.Title,
+		in.Summary,
+		in.ContentHTML,
+		in.ContentMD,
+		in.Author,
+		timeToDBString(in.PublishedAt),
+		timeToDBString(in.DateModified),
+	} {
+		if s, ok := v.(string); ok {
+			h.Write([]byte(s))
+		}
+		h.Write([]byte{0})
+	}
+	// Only mixed in when present, so entries without enclosures keep the
+	// hash they had before enclosures were stored.
+	for _, enc := range in.Enclosures {
+		fmt.Fprintf(h, "enclosure\x00%s\x00%s\x00%d\x00%d\x00", enc.URL, enc.MimeType, enc.Length, enc.DurationSeconds)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func prepareEntryUpserter(ctx context.Context, tx *sql.Tx) (u *entryUpserter, err error) {
+	u = &entryUpserter{}
+	defer func() {
+		if err != nil {
+			u.Close()
+		}
+	}()
+	if u.lookup, err = tx.PrepareContext(ctx, `SELECT id, content_hash, source_hash FROM entries WHERE feed_id = ? AND guid = ?`); err != nil {
+		return nil, err
+	}
+	if u.insert, err = tx.PrepareContext(ctx, `
+		INSERT INTO entries (
+			feed_id, guid, url, external_url, title, summary,
+			content_html, content_md, author, published_at, date_modified, content_hash, source_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+	`); err != nil {
+		return nil, err
+	}
+	// Rows without a content hash predate it; the WHERE clause compares
+	// their columns directly so an unchanged legacy row is not rewritten.
+	// A changed body invalidates the extracted article, so it is fetched
+	// again, unless ?12 says the feed's item itself is unchanged.
+	if u.update, err = tx.PrepareContext(ctx, `
+		UPDATE entries SET
+			url = ?1,
+			external_url = ?2,
+			title = ?3,
+			summary = ?4,
+			content_html = ?5,
+			content_md = ?6,
+			author = ?7,
+			published_at = ?8,
+			date_modified = ?9,
+			content_hash = ?11,
+			source_hash = COALESCE(NULLIF(?13, ''), source_hash),
+			fetched_at = CURRENT_TIMESTAMP,
+			full_content_fetched_at = CASE WHEN NOT ?12 AND (content_html IS NOT ?5 OR content_md IS NOT ?6)
+				THEN NULL ELSE full_content_fetched_at END,
+			full_content_attempts = CASE WHEN NOT ?12 AND (content_html IS NOT ?5 OR content_md IS NOT ?6)
+				THEN 0 ELSE full_content_attempts END
+		WHERE id = ?10 AND (
+			url IS NOT ?1 OR
+			external_url IS NOT ?2 OR
+			title IS NOT ?3 OR
+			summary IS NOT ?4 OR
+			content_html IS NOT ?5 OR
+			content_md IS NOT ?6 OR
+			author IS NOT ?7 OR
+			published_at IS NOT ?8 OR
+			date_modified IS NOT ?9
+		)
+	`); err != nil {
+		return nil, err
+	}
+	// The previous version is kept only when the article body itself changed,
+	// not for metadata-only edits such as a new URL or date.
+	if u.archive, err = tx.PrepareContext(ctx, `
+		INSERT INTO entry_revisions (entry_id, title, content_html, content_md, fetched_at)
+		SELECT id, title, content_html, content_md, fetched_at
+		FROM entries
+		WHERE id = ?1 AND (content_html IS NOT ?2 OR content_md IS NOT ?3)
+	`); err != nil {
+		return nil, err
+	}
+	if u.rehash, err = tx.PrepareContext(ctx, `
+		UPDATE entries SET content_hash = ?1, source_hash = COALESCE(NULLIF(?2, ''), source_hash) WHERE id = ?3
+	`); err != nil {
+		return nil, err
+	}
+	if u.sameMetadata, err = tx.PrepareContext(ctx, `
+		SELECT url IS ?2 AND title IS ?3 AND author IS ?4 AND published_at IS ?5 AND date_modified IS ?6
+		FROM entries WHERE id = ?1
+	`); err != nil {
+		return nil, err
+	}
+	if u.status, err = tx.PrepareContext(ctx, `INSERT OR IGNORE INTO entry_status(entry_id) VALUES (?)`); err != nil {
+		return nil, err
+	}
+	if u.listEnclosures, err = tx.PrepareContext(ctx, `
+		SELECT url, COALESCE(mime_type, ''), COALESCE(length, 0), COALESCE(duration_seconds, 0)
+		FROM enclosures WHERE entry_id = ? ORDER BY position
+	`); err != nil {
+		return nil, err
+	}
+	if u.clearEnclosure, err = tx.PrepareContext(ctx, `DELETE FROM enclosures WHERE entry_id = ?`); err != nil {
+		return nil, err
+	}
+	if u.addEnclosure, err = tx.PrepareContext(ctx, `
+		INSERT INTO enclosures (entry_id, position, url, mime_type, length, duration_seconds)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, 0), NULLIF(?, 0))
+	`); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *entryUpserter) Close() {
+	for _, stmt := range []*sql.Stmt{
+		u.lookup, u.insert, u.update, u.archive, u.rehash, u.sameMetadata, u.status,
+		u.listEnclosures, u.clearEnclosure, u.addEnclosure,
+	} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+}
+
+func (u *entryUpserter) upsert(ctx context.Context, in UpsertEntryInput) (int64, upsertOutcome, error) {
+	hash := entryContentHash(in)
+	var id int64
+	var storedHash, storedSource sql.NullString
+	err := u.lookup.QueryRowContext(ctx, in.FeedID, in.GUID).Scan(&id, &storedHash, &storedSource)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		res, err := u.insert.ExecContext(ctx,
+			in.FeedID,
+			in.GUID,
+			in.URL,
+			in.ExternalURL,
+			in.Title,
+			in.Summary,
+			in.ContentHTML,
+			in.ContentMD,
+			in.Author,
+			timeToDBString(in.PublishedAt),
+			timeToDBString(in.DateModified),
+			hash,
+			in.SourceHash,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return 0, 0, err
+		}
+		if _, err := u.status.ExecContext(ctx, id); err != nil {
+			return 0, 0, err
+		}
+		if err := u.writeEnclosures(ctx, id, in.Enclosures); err != nil {
+			return 0, 0, err
+		}
+		return id, upsertInserted, nil
+	case err != nil:
+		return 0, 0, err
+	}
+	if storedHash.String == hash {
+		if in.SourceHash != "" && storedSource.String != in.SourceHash {
+			if _, err := u.rehash.ExecContext(ctx, hash, in.SourceHash, id); err != nil {
+				return 0, 0, err
+			}
+		}
+		return id, upsertUnchanged, nil
+	}
+	// An item the feed still publishes as before only changed in how it was
+	// sanitized or rendered, say after an upgrade: its stored content is
+	// refreshed without archiving a revision or counting as updated. Rows
+	// stored before source hashes were recorded have nothing to compare, so
+	// they count as reprocessed only when their title, URL, author and dates
+	// are unchanged too.
+	reprocessed := in.SourceHash != "" && storedSource.String == in.SourceHash
+	if in.SourceHash != "" && !storedSource.Valid {
+		err := u.sameMetadata.QueryRowContext(ctx, id, in.URL, in.Title, in.Author,
+			timeToDBString(in.PublishedAt), timeToDBString(in.DateModified)).Scan(&reprocessed)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if !reprocessed {
+		if _, err := u.archive.ExecContext(ctx, id, in.ContentHTML, in.ContentMD); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	res, err := u.update.ExecContext(ctx,
+		in.URL,
+		in.ExternalURL,
+		in.Title,
+		in.Summary,
+		in.ContentHTML,
+		in.ContentMD,
+		in.Author,
+		timeToDBString(in.PublishedAt),
+		timeToDBString(in.DateModified),
+		id,
+		hash,
+		reprocessed,
+		in.SourceHash,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	enclosuresChanged, err := u.syncEnclosures(ctx, id, in.Enclosures)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := u.rehash.ExecContext(ctx, hash, in.SourceHash, id); err != nil {
+			return 0, 0, err
+		}
+		if !enclosuresChanged {
+			return id, upsertUnchanged, nil
+		}
+	}
+	if reprocessed {
+		return id, upsertUnchanged, nil
+	}
+	return id, upsertUpdated, nil
+}
+
+// syncEnclosures replaces the entry's stored enclosures when they differ
+// from enclosures and reports whether anything changed.
+func (u *entryUpserter) syncEnclosures(ctx context.Context, entryID int64, enclosures []Enclosure) (bool, error) {
+	rows, err := u.listEnclosures.QueryContext(ctx, entryID)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	stored := make([]Enclosure, 0, len(enclosures))
+	for rows.Next() {
+		var enc Enclosure
+		if err := rows.Scan(&enc.URL, &enc.MimeType, &enc.Length, &enc.DurationSeconds); err != nil {
+			return false, err
+		}
+		stored = append(stored, enc)
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if slices.Equal(stored, enclosures) {
+		return false, nil
+	}
+	if _, err := u.clearEnclosure.ExecContext(ctx, entryID); err != nil {
+		return false, err
+	}
+	return true, u.writeEnclosures(ctx, entryID, enclosures)
+}
+
+func (u *entryUpserter) writeEnclosures(ctx context.Context, entryID int64, enclosures []Enclosure) error {
+	for i, enc := range enclosures {
+		if _, err := u.addEnclosure.ExecContext(ctx, entryID, i, enc.URL, enc.MimeType, enc.Length, enc.DurationSeconds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpsertEntry(ctx context.Context, in UpsertEntryInput) (entryID int64, inserted bool, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	u, err := prepareEntryUpserter(ctx, tx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer u.Close()
+
+	entryID, outcome, err := u.upsert(ctx, in)
+	if err != nil {
+		return 0, false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return entryID, outcome == upsertInserted, nil
+}
+
+// UpsertEntries writes a batch of entries in one transaction. Entries whose
+// stored content already matches are left untouched and counted in neither
+// inserted nor updated.
+func (s *Store) UpsertEntries(ctx context.Context, entries []UpsertEntryInput) (inserted, updated int, err error) {
+	if len(entries) == 0 {
+		return 0, 0, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	u, err := prepareEntryUpserter(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer u.Close()
+
+	for _, in := range entries {
+		_, outcome, err := u.upsert(ctx, in)
+		if err != nil {
+			return 0, 0, err
+		}
+		switch outcome {
+		case upsertInserted:
+			inserted++
+		case upsertUpdated:
+			updated++
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return inserted, updated, nil
+}
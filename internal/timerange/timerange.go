@@ -0,0 +1,83 @@
+// Package timerange parses the since/until bounds shared by the CLI, the
+// HTTP API and the MCP server.
+package timerange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var relativeRegexp = regexp.MustCompile(`^(\d+)([smhdw])$`)
+
+const dateLayout = "2006-01-02"
+
+// ParseDuration accepts Go durations (90m, 1h30m) plus day and week
+// suffixes (2d, 1w).
+func ParseDuration(raw string) (time.Duration, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if m := relativeRegexp.FindStringSubmatch(raw); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, false
+		}
+		unit := map[string]time.Duration{
+			"s": time.Second,
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+			"w": 7 * 24 * time.Hour,
+		}[m[2]]
+		return time.Duration(n) * unit, true
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// Since parses a lower bound: an RFC3339 timestamp, a local date or time
+// such as 2026-01-31 or 2026-01-31T08:00, or a duration ago such as 6h or
+// 2d. An empty value is no bound.
+func Since(raw string, now time.Time) (*time.Time, error) {
+	t, _, err := parse(raw, now)
+	return t, err
+}
+
+// Until parses an upper bound like Since, except that a bare date includes
+// the whole day: --until 2026-01-31 ends at the last instant of January 31.
+func Until(raw string, now time.Time) (*time.Time, error) {
+	t, dateOnly, err := parse(raw, now)
+	if err != nil || t == nil || !dateOnly {
+		return t, err
+	}
+	end := t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return &end, nil
+}
+
+func parse(raw string, now time.Time) (t *time.Time, dateOnly bool, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, false, nil
+	}
+
+	if d, ok := ParseDuration(raw); ok && d > 0 {
+		t := now.Add(-d)
+		return &t, false, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, false, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", dateLayout} {
+		if t, err := time.ParseInLocation(layout, raw, now.Location()); err == nil {
+			return &t, layout == dateLayout, nil
+		}
+	}
+	return nil, false, fmt.Errorf("invalid time %q (expected a date like 2026-01-31, an RFC3339 timestamp, or a duration like 6h or 2d)", raw)
+}
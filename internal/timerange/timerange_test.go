@@ -0,0 +1,73 @@
+package timerange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := Since("2d", now)
+	if err != nil {
+		t.Fatalf("parse 2d: %v", err)
+	}
+	if want := now.Add(-48 * time.Hour); !got.Equal(want) {
+		t.Fatalf("2d = %s, want %s", got, want)
+	}
+
+	got, err = Since("90m", now)
+	if err != nil {
+		t.Fatalf("parse 90m: %v", err)
+	}
+	if want := now.Add(-90 * time.Minute); !got.Equal(want) {
+		t.Fatalf("90m = %s, want %s", got, want)
+	}
+
+	got, err = Since("2026-03-01T08:00:00Z", now)
+	if err != nil {
+		t.Fatalf("parse rfc3339: %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("rfc3339 = %s, want %s", got, want)
+	}
+
+	got, err = Since("2026-03-01", now)
+	if err != nil {
+		t.Fatalf("parse date: %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("date = %s, want %s", got, want)
+	}
+
+	if got, err := Since("  ", now); err != nil || got != nil {
+		t.Fatalf("empty bound = %v, %v; want nil, nil", got, err)
+	}
+	if _, err := Since("yesterday", now); err == nil {
+		t.Fatalf("expected error for unsupported value")
+	}
+}
+
+func TestUntilIncludesWholeDay(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, loc)
+
+	got, err := Until("2026-01-31", now)
+	if err != nil {
+		t.Fatalf("parse date: %v", err)
+	}
+	if evening := time.Date(2026, 1, 31, 23, 30, 0, 0, loc); got.Before(evening) {
+		t.Fatalf("until 2026-01-31 = %s, want it to include the evening of that day", got)
+	}
+	if next := time.Date(2026, 2, 1, 0, 0, 0, 0, loc); !got.Before(next) {
+		t.Fatalf("until 2026-01-31 = %s, want it to end before February 1", got)
+	}
+
+	got, err = Until("2026-01-31T08:00", now)
+	if err != nil {
+		t.Fatalf("parse time: %v", err)
+	}
+	if want := time.Date(2026, 1, 31, 8, 0, 0, 0, loc); !got.Equal(want) {
+		t.Fatalf("until with a time = %s, want %s", got, want)
+	}
+}